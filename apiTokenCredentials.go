@@ -9,5 +9,8 @@ type APITokenCredentials struct {
 
 // APITokenCredentialsAdditionalProperties contains the non standard fields for this type of credentials
 type APITokenCredentialsAdditionalProperties struct {
-	Token string `json:"token,omitempty"`
+	Token             string `json:"token,omitempty"`
+	CABundle          string `json:"caBundle,omitempty"`
+	ClientCertificate string `json:"clientCertificate,omitempty"`
+	ClientKey         string `json:"clientKey,omitempty"`
 }
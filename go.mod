@@ -8,7 +8,6 @@ require (
 	github.com/alecthomas/units v0.0.0-20190924025748-f65c72e2690d // indirect
 	github.com/estafette/estafette-foundation v0.0.37
 	github.com/rs/zerolog v1.17.2
-	github.com/sethgrid/pester v0.0.0-20190127155807-68a33a018ad0
 	github.com/stretchr/testify v1.4.0
 	gopkg.in/yaml.v2 v2.2.2
 )
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"runtime"
+	"time"
 
 	"github.com/alecthomas/kingpin"
+	"github.com/estafette/estafette-extension-github-release/pkg/gitea"
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/estafette/estafette-extension-github-release/pkg/gitlab"
+	"github.com/estafette/estafette-extension-github-release/pkg/release"
 	foundation "github.com/estafette/estafette-foundation"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v2"
 )
@@ -23,17 +31,24 @@ var (
 
 var (
 	// flags
-	apiTokenJSONPath = kingpin.Flag("credentials-path", "Path to file with Github api token credentials configured at the CI server, passed in to this trusted extension.").Default("/credentials/github_api_token.json").String()
-	gitRepoOwner     = kingpin.Flag("git-repo-owner", "The owner of the Github repository.").Envar("ESTAFETTE_GIT_OWNER").Required().String()
-	gitRepoName      = kingpin.Flag("git-repo-name", "The name of the Github repository.").Envar("ESTAFETTE_GIT_NAME").Required().String()
-	gitRevision      = kingpin.Flag("git-revision", "The hash of the revision to set build status for.").Envar("ESTAFETTE_GIT_REVISION").Required().String()
-	buildVersion     = kingpin.Flag("build-version", "The version of the pipeline.").Envar("ESTAFETTE_BUILD_VERSION").String()
+	apiTokenJSONPath       = kingpin.Flag("credentials-path", "Path to file with Github api token credentials configured at the CI server, passed in to this trusted extension.").Default("/credentials/github_api_token.json").String()
+	gitlabAPITokenJSONPath = kingpin.Flag("gitlab-credentials-path", "Path to file with Gitlab api token credentials configured at the CI server, passed in to this trusted extension.").Default("/credentials/gitlab_api_token.json").String()
+	giteaAPITokenJSONPath  = kingpin.Flag("gitea-credentials-path", "Path to file with Gitea api token credentials configured at the CI server, passed in to this trusted extension.").Default("/credentials/gitea_api_token.json").String()
+	notificationJSONPath   = kingpin.Flag("notification-credentials-path", "Path to file with release notification credentials configured at the CI server, passed in to this trusted extension.").Default("/credentials/release_notification.json").String()
+	gitRepoOwner           = kingpin.Flag("git-repo-owner", "The owner of the Github repository.").Envar("ESTAFETTE_GIT_OWNER").Required().String()
+	gitRepoName            = kingpin.Flag("git-repo-name", "The name of the Github repository.").Envar("ESTAFETTE_GIT_NAME").Required().String()
+	gitRevision            = kingpin.Flag("git-revision", "The hash of the revision to set build status for.").Envar("ESTAFETTE_GIT_REVISION").Required().String()
+	gitSource              = kingpin.Flag("git-source", "The source of the git repository, like github.com, gitlab.com or the host of a self-hosted Github Enterprise, Gitlab or Gitea instance.").Envar("ESTAFETTE_GIT_SOURCE").Default("github.com").String()
+	gitBranch              = kingpin.Flag("git-branch", "The branch of the git repository the build ran for.").Envar("ESTAFETTE_GIT_BRANCH").String()
+	buildVersion           = kingpin.Flag("build-version", "The version of the pipeline.").Envar("ESTAFETTE_BUILD_VERSION").String()
 
 	paramsYAML = kingpin.Flag("params-yaml", "Extension parameters, created from custom properties.").Envar("ESTAFETTE_EXTENSION_CUSTOM_PROPERTIES_YAML").Required().String()
 )
 
 func main() {
 
+	startedOn := time.Now().UTC()
+
 	// parse command line parameters
 	kingpin.Parse()
 
@@ -41,84 +56,165 @@ func main() {
 	foundation.InitLoggingFromEnv(appgroup, app, version, branch, revision, buildDate)
 
 	log.Info().Msg("Unmarshalling parameters...")
-	var params Params
+	var params release.Params
 	err := yaml.Unmarshal([]byte(*paramsYAML), &params)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed unmarshalling parameters")
 	}
 
+	// determine the provider from the git source, unless set explicitly
+	provider, apiBaseURL, err := release.GetProviderSettings(*gitSource, params.Provider, params.APIBaseURL)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Determining provider failed")
+	}
+	credentialsPath := *apiTokenJSONPath
+	switch provider {
+	case release.ProviderGitlab:
+		credentialsPath = *gitlabAPITokenJSONPath
+	case release.ProviderGitea:
+		credentialsPath = *giteaAPITokenJSONPath
+	}
+	credentialsType := fmt.Sprintf("%v-api-token", provider)
+
 	// get api token from injected credentials
 	var credentials []APITokenCredentials
 	// use mounted credential file if present instead of relying on an envvar
 	if runtime.GOOS == "windows" {
-		*apiTokenJSONPath = "C:" + *apiTokenJSONPath
+		credentialsPath = "C:" + credentialsPath
 	}
-	if foundation.FileExists(*apiTokenJSONPath) {
-		log.Info().Msgf("Reading credentials from file at path %v...", *apiTokenJSONPath)
-		credentialsFileContent, err := ioutil.ReadFile(*apiTokenJSONPath)
+	if foundation.FileExists(credentialsPath) {
+		log.Info().Msgf("Reading credentials from file at path %v...", credentialsPath)
+		credentialsFileContent, err := ioutil.ReadFile(credentialsPath)
 		if err != nil {
-			log.Fatal().Msgf("Failed reading credential file at path %v.", *apiTokenJSONPath)
+			log.Fatal().Msgf("Failed reading credential file at path %v.", credentialsPath)
 		}
 		err = json.Unmarshal(credentialsFileContent, &credentials)
 		if err != nil {
 			log.Fatal().Err(err).Msg("Failed unmarshalling injected credentials")
 		}
 	} else {
-		log.Fatal().Msg("Credentials of type github-api-token are not injected; configure this extension as trusted and inject credentials of type github-api-token")
+		log.Fatal().Msgf("Credentials of type %v are not injected; configure this extension as trusted and inject credentials of type %v", credentialsType, credentialsType)
 	}
 	if len(credentials) == 0 {
-		log.Fatal().Msg("Credentials of type github-api-token are not injected; configure this extension as trusted and inject credentials of type github-api-token")
+		log.Fatal().Msgf("Credentials of type %v are not injected; configure this extension as trusted and inject credentials of type %v", credentialsType, credentialsType)
+	}
+
+	// get notification urls and secrets from injected credentials
+	notificationCredentialsPath := *notificationJSONPath
+	if runtime.GOOS == "windows" {
+		notificationCredentialsPath = "C:" + notificationCredentialsPath
+	}
+	notificationCredentials, err := readNotificationCredentials(notificationCredentialsPath, params.Notifications)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Reading notification credentials failed")
+	}
+	err = injectNotificationCredentials(params.Notifications, notificationCredentials)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Injecting notification credentials failed")
 	}
 
 	// set defaults
 	params.SetDefaults(*buildVersion, *gitRepoName)
 
-	// set build status
-	githubAPIClient := newGithubAPIClient(credentials[0].AdditionalProperties.Token)
+	// cancel in-flight requests when estafette aborts the build or the stage deadline passes
+	ctx, cancel := context.WithTimeout(foundation.InitCancellationContext(context.Background()), time.Duration(params.TimeoutSeconds)*time.Second)
+	defer cancel()
+	requestTimeout := time.Duration(params.RequestTimeoutSeconds) * time.Second
 
-	// get milestone by version
-	milestone, err := githubAPIClient.GetMilestoneByVersion(*gitRepoOwner, *gitRepoName, params.ReleaseVersion)
-	if !params.IgnoreMissingMilestone {
-		if err != nil {
-			log.Fatal().Err(err).Msgf("Retrieving milestone failed. Please create a milestone with title %v if it does not exist.", params.ReleaseVersion)
-		}
-		if milestone == nil {
-			log.Fatal().Msgf("Milestone does not exist. Please create a milestone with title %v and retry", params.ReleaseVersion)
+	// configure the http middleware shared by all providers
+	credentialsTLSOptions := github.TLSOptions{
+		CABundle:          []byte(credentials[0].AdditionalProperties.CABundle),
+		ClientCertificate: []byte(credentials[0].AdditionalProperties.ClientCertificate),
+		ClientKey:         []byte(credentials[0].AdditionalProperties.ClientKey),
+	}
+	tlsOptions, err := credentialsTLSOptions.WithFiles(params.CABundlePath, params.ClientCertificatePath, params.ClientKeyPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Reading certificates failed")
+	}
+	baseTransport, err := github.NewBaseTransport(tlsOptions)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Configuring tls failed")
+	}
+	transportMetrics := &github.Metrics{}
+	var harRecorder *github.HARRecorder
+	if params.Debug {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+		if params.HARFile != "" {
+			harRecorder = github.NewHARRecorder(params.HARFile, app, version)
 		}
 	}
+	clientOptions := github.Options{
+		BaseURL:        apiBaseURL,
+		AccessToken:    credentials[0].AdditionalProperties.Token,
+		RequestTimeout: requestTimeout,
+		UserAgent:      fmt.Sprintf("%v/%v", app, version),
+		MaxRetries:     3,
+		Metrics:        transportMetrics,
+		Transport:      baseTransport,
+		Debug:          params.Debug,
+		HAR:            harRecorder,
+	}
 
-	var issues []*githubIssue
-	var pullRequests []*githubPullRequest
+	// gitlab and gitea only support the core of the release flow
+	if provider != release.ProviderGithub {
+		var releaseProvider release.Provider = gitlab.NewAPIClient(clientOptions)
+		if provider == release.ProviderGitea {
+			releaseProvider = gitea.NewAPIClient(clientOptions)
+		}
 
-	if milestone != nil {
-		// retrieve issues for milestone
-		issues, pullRequests, err = githubAPIClient.GetIssuesAndPullRequestsForMilestone(*gitRepoOwner, *gitRepoName, *milestone)
+		err = release.RunProvider(ctx, releaseProvider, release.Options{
+			GitSource:   *gitSource,
+			RepoOwner:   *gitRepoOwner,
+			RepoName:    *gitRepoName,
+			GitRevision: *gitRevision,
+			GitBranch:   *gitBranch,
+			Params:      params,
+			StartedOn:   startedOn,
+			Transport:   baseTransport,
+		})
+		if closeErr := harRecorder.Close(); closeErr != nil {
+			log.Warn().Err(closeErr).Msgf("Closing har file %v failed", params.HARFile)
+		}
+		log.Info().Msg(transportMetrics.Summary())
 		if err != nil {
-			log.Fatal().Err(err).Msgf("Retrieving issues and pull requests for milestone #%v failed", milestone.Number)
+			github.WithAPIErrorFields(log.Fatal(), err).Msgf("Creating release in %v failed", provider)
 		}
-	}
 
-	// create release
-	createdRelease, err := githubAPIClient.CreateRelease(*gitRepoOwner, *gitRepoName, *gitRevision, params.ReleaseVersion, milestone, issues, pullRequests, params)
-	if err != nil {
-		log.Fatal().Err(err).Msgf("Creating release with name %v failed", params.ReleaseVersion)
+		log.Info().Msg("Finished estafette-extension-github-release...")
+		return
 	}
 
-	// upload assets
-	if createdRelease != nil {
-		err = githubAPIClient.UploadReleaseAssets(*createdRelease, params.Assets)
+	githubAPIClientOptions := clientOptions
+	if params.CacheDirectory != "" {
+		githubAPIClientOptions.Cache, err = github.NewResponseCache(params.CacheDirectory)
 		if err != nil {
-			log.Fatal().Err(err).Msgf("Uploading assets %v failed", params.ReleaseVersion)
+			log.Warn().Err(err).Msgf("Creating cache directory %v failed, continuing without cache", params.CacheDirectory)
 		}
 	}
+	githubAPIClient := github.NewAPIClient(githubAPIClientOptions)
+	if params.UseGraphQL {
+		githubAPIClient = github.NewGraphQLAPIClient(githubAPIClientOptions)
+	}
 
-	// close milestone
-	if milestone != nil && params.CloseMilestone != nil && *params.CloseMilestone {
-		err = githubAPIClient.CloseMilestone(*gitRepoOwner, *gitRepoName, *milestone)
-		if err != nil {
-			log.Fatal().Err(err).Msgf("Closing milestone #%v failed", milestone.Number)
-		}
+	err = release.Run(ctx, githubAPIClient, release.Options{
+		GitSource:   *gitSource,
+		RepoOwner:   *gitRepoOwner,
+		RepoName:    *gitRepoName,
+		GitRevision: *gitRevision,
+		GitBranch:   *gitBranch,
+		Params:      params,
+		StartedOn:   startedOn,
+		Transport:   baseTransport,
+	})
+	if closeErr := harRecorder.Close(); closeErr != nil {
+		log.Warn().Err(closeErr).Msgf("Closing har file %v failed", params.HARFile)
 	}
+	if err != nil {
+		log.Info().Msg(transportMetrics.Summary())
+		github.WithAPIErrorFields(log.Fatal(), err).Msg("Releasing failed")
+	}
+
+	log.Info().Msg(transportMetrics.Summary())
 
 	log.Info().Msg("Finished estafette-extension-github-release...")
 }
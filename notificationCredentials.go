@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/release"
+	foundation "github.com/estafette/estafette-foundation"
+)
+
+// NotificationCredentials represents the credentials of type release-notification as defined in the server config and passed to this trusted image
+type NotificationCredentials struct {
+	Name                 string                                      `json:"name,omitempty"`
+	Type                 string                                      `json:"type,omitempty"`
+	AdditionalProperties NotificationCredentialsAdditionalProperties `json:"additionalProperties,omitempty"`
+}
+
+// NotificationCredentialsAdditionalProperties contains the secrets of a notification sink
+type NotificationCredentialsAdditionalProperties struct {
+	URL          string `json:"url,omitempty"`
+	Secret       string `json:"secret,omitempty"`
+	SMTPPassword string `json:"smtpPassword,omitempty"`
+}
+
+// readNotificationCredentials reads the injected notification credentials, if any of the notifications refers to them
+func readNotificationCredentials(path string, notifications []release.NotificationParams) (credentials []NotificationCredentials, err error) {
+
+	needsCredentials := false
+	for _, n := range notifications {
+		if n.Credentials != "" {
+			needsCredentials = true
+		}
+	}
+	if !needsCredentials {
+		return
+	}
+
+	if !foundation.FileExists(path) {
+		return nil, fmt.Errorf("Credentials of type release-notification are not injected; configure this extension as trusted and inject credentials of type release-notification")
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed reading credential file at path %v: %w", path, err)
+	}
+	err = json.Unmarshal(content, &credentials)
+	if err != nil {
+		return nil, fmt.Errorf("Failed unmarshalling injected notification credentials: %w", err)
+	}
+
+	return credentials, nil
+}
+
+// injectNotificationCredentials sets the url, secret and smtp password of each notification from the credentials it refers to by name
+func injectNotificationCredentials(notifications []release.NotificationParams, credentials []NotificationCredentials) error {
+
+	for i, n := range notifications {
+		if n.Credentials == "" {
+			continue
+		}
+
+		found := false
+		for _, c := range credentials {
+			if c.Name == n.Credentials {
+				notifications[i].URL = c.AdditionalProperties.URL
+				notifications[i].Secret = c.AdditionalProperties.Secret
+				notifications[i].SMTPPassword = c.AdditionalProperties.SMTPPassword
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("Notification %v refers to credentials %v, which are not injected", i+1, n.Credentials)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/release"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadNotificationCredentials(t *testing.T) {
+
+	t.Run("ReturnsNoCredentialsIfNoNotificationRefersToThem", func(t *testing.T) {
+
+		// act
+		credentials, err := readNotificationCredentials("/does/not/exist.json", []release.NotificationParams{{Type: "email"}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(credentials))
+	})
+
+	t.Run("ReturnsErrorIfCredentialsAreNotInjected", func(t *testing.T) {
+
+		// act
+		_, err := readNotificationCredentials("/does/not/exist.json", []release.NotificationParams{{Type: "slack", Credentials: "slack-releases"}})
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReadsCredentialsFromFile", func(t *testing.T) {
+
+		directory, _ := ioutil.TempDir("", "credentials")
+		defer os.RemoveAll(directory)
+		path := filepath.Join(directory, "release_notification.json")
+		ioutil.WriteFile(path, []byte(`[{"name":"slack-releases","type":"release-notification","additionalProperties":{"url":"https://hooks.slack.com/services/abc"}}]`), 0644)
+
+		// act
+		credentials, err := readNotificationCredentials(path, []release.NotificationParams{{Type: "slack", Credentials: "slack-releases"}})
+
+		assert.Nil(t, err)
+		if assert.Equal(t, 1, len(credentials)) {
+			assert.Equal(t, "https://hooks.slack.com/services/abc", credentials[0].AdditionalProperties.URL)
+		}
+	})
+}
+
+func TestInjectNotificationCredentials(t *testing.T) {
+
+	t.Run("SetsSecretsFromCredentialsWithMatchingName", func(t *testing.T) {
+
+		notifications := []release.NotificationParams{
+			{Type: "webhook", Credentials: "release-webhook"},
+			{Type: "email", Credentials: "smtp", SMTPHost: "smtp.example.com"},
+		}
+		credentials := []NotificationCredentials{
+			{Name: "smtp", AdditionalProperties: NotificationCredentialsAdditionalProperties{SMTPPassword: "password"}},
+			{Name: "release-webhook", AdditionalProperties: NotificationCredentialsAdditionalProperties{URL: "https://releases.example.com/hooks", Secret: "secret"}},
+		}
+
+		// act
+		err := injectNotificationCredentials(notifications, credentials)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "https://releases.example.com/hooks", notifications[0].URL)
+		assert.Equal(t, "secret", notifications[0].Secret)
+		assert.Equal(t, "password", notifications[1].SMTPPassword)
+	})
+
+	t.Run("ReturnsErrorForUnknownCredentials", func(t *testing.T) {
+
+		notifications := []release.NotificationParams{{Type: "slack", Credentials: "slack-releases"}}
+
+		// act
+		err := injectNotificationCredentials(notifications, []NotificationCredentials{})
+
+		assert.NotNil(t, err)
+	})
+}
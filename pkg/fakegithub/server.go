@@ -0,0 +1,1000 @@
+// Package fakegithub provides an in-memory Github api on top of httptest, so the release flow can be tested end-to-end without network access.
+package fakegithub
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server serves the part of the Github rest api used by the extension for a single repository
+type Server struct {
+	*httptest.Server
+
+	// PageSize is the maximum number of items per page of a list, regardless of the requested per_page
+	PageSize int
+	// Scopes is returned in the X-OAuth-Scopes header
+	Scopes string
+
+	owner      string
+	repo       string
+	mutex      sync.Mutex
+	nextID     int
+	milestones []*Milestone
+	issues     []*Issue
+	comments   []*Comment
+	releases   []*Release
+	commits    []*Commit
+	tags       map[string]string
+	tagObjects []*TagObject
+	failures   []*failure
+	requests   []string
+	routes     []route
+}
+
+// Milestone is a milestone in the repository
+type Milestone struct {
+	Number      int
+	Title       string
+	State       string
+	Description string
+}
+
+// Issue is an issue or, with IsPullRequest set, a pull request in the repository
+type Issue struct {
+	Number        int
+	Title         string
+	State         string
+	Body          string
+	Milestone     int
+	Labels        []string
+	Assignee      string
+	User          string
+	IsPullRequest bool
+}
+
+// Comment is a comment on an issue or pull request
+type Comment struct {
+	ID          int
+	IssueNumber int
+	Body        string
+}
+
+// Release is a release with its uploaded assets
+type Release struct {
+	ID              int
+	TagName         string
+	TargetCommitish string
+	Name            string
+	Body            string
+	Draft           bool
+	PreRelease      bool
+	Assets          []*Asset
+}
+
+// Asset is a file uploaded to a release
+type Asset struct {
+	ID          int
+	Name        string
+	Label       string
+	ContentType string
+	Content     []byte
+}
+
+// Commit is a commit that tags, releases and status checks refer to
+type Commit struct {
+	SHA     string
+	Message string
+	Date    time.Time
+}
+
+// TagObject is an annotated tag
+type TagObject struct {
+	SHA       string
+	Tag       string
+	Message   string
+	ObjectSHA string
+}
+
+// failure makes requests matching the method and path fail with the status code
+type failure struct {
+	method     string
+	path       string
+	statusCode int
+	times      int
+}
+
+type route struct {
+	method  string
+	pattern *regexp.Regexp
+	handler func(w http.ResponseWriter, r *http.Request, params []string)
+}
+
+// NewServer starts a fake Github api for the repository; close it after use
+func NewServer(owner, repo string) *Server {
+	s := &Server{
+		PageSize: 100,
+		Scopes:   "repo",
+		owner:    owner,
+		repo:     repo,
+		nextID:   1,
+		tags:     map[string]string{},
+	}
+
+	repoPath := fmt.Sprintf("/repos/%v/%v", regexp.QuoteMeta(owner), regexp.QuoteMeta(repo))
+	s.routes = []route{
+		{"GET", regexp.MustCompile(`^/$`), s.getRoot},
+		{"GET", regexp.MustCompile(`^` + repoPath + `$`), s.getRepository},
+		{"GET", regexp.MustCompile(`^` + repoPath + `/milestones$`), s.listMilestones},
+		{"POST", regexp.MustCompile(`^` + repoPath + `/milestones$`), s.createMilestone},
+		{"PATCH", regexp.MustCompile(`^` + repoPath + `/milestones/(\d+)$`), s.updateMilestone},
+		{"DELETE", regexp.MustCompile(`^` + repoPath + `/milestones/(\d+)$`), s.deleteMilestone},
+		{"GET", regexp.MustCompile(`^` + repoPath + `/issues$`), s.listIssues},
+		{"GET", regexp.MustCompile(`^` + repoPath + `/issues/(\d+)$`), s.getIssue},
+		{"PATCH", regexp.MustCompile(`^` + repoPath + `/issues/(\d+)$`), s.updateIssue},
+		{"POST", regexp.MustCompile(`^` + repoPath + `/issues/(\d+)/labels$`), s.addLabels},
+		{"DELETE", regexp.MustCompile(`^` + repoPath + `/issues/(\d+)/labels/([^/]+)$`), s.removeLabel},
+		{"GET", regexp.MustCompile(`^` + repoPath + `/issues/(\d+)/comments$`), s.listComments},
+		{"POST", regexp.MustCompile(`^` + repoPath + `/issues/(\d+)/comments$`), s.createComment},
+		{"DELETE", regexp.MustCompile(`^` + repoPath + `/issues/comments/(\d+)$`), s.deleteComment},
+		{"GET", regexp.MustCompile(`^` + repoPath + `/tags$`), s.listTags},
+		{"GET", regexp.MustCompile(`^` + repoPath + `/commits/([^/]+)$`), s.getCommit},
+		{"GET", regexp.MustCompile(`^` + repoPath + `/commits/([^/]+)/status$`), s.getCombinedStatus},
+		{"GET", regexp.MustCompile(`^` + repoPath + `/commits/([^/]+)/check-runs$`), s.listCheckRuns},
+		{"GET", regexp.MustCompile(`^` + repoPath + `/commits/([^/]+)/pulls$`), s.listPullRequestsForCommit},
+		{"GET", regexp.MustCompile(`^` + repoPath + `/git/ref/tags/(.+)$`), s.getTagRef},
+		{"POST", regexp.MustCompile(`^` + repoPath + `/git/refs$`), s.createRef},
+		{"PATCH", regexp.MustCompile(`^` + repoPath + `/git/refs/tags/(.+)$`), s.updateTagRef},
+		{"DELETE", regexp.MustCompile(`^` + repoPath + `/git/refs/tags/(.+)$`), s.deleteTagRef},
+		{"GET", regexp.MustCompile(`^` + repoPath + `/git/tags/([^/]+)$`), s.getTagObject},
+		{"POST", regexp.MustCompile(`^` + repoPath + `/git/tags$`), s.createTagObject},
+		{"POST", regexp.MustCompile(`^` + repoPath + `/releases$`), s.createRelease},
+		{"GET", regexp.MustCompile(`^` + repoPath + `/releases/tags/(.+)$`), s.getReleaseByTag},
+		{"DELETE", regexp.MustCompile(`^` + repoPath + `/releases/(\d+)$`), s.deleteRelease},
+		{"POST", regexp.MustCompile(`^/uploads` + repoPath + `/releases/(\d+)/assets$`), s.uploadAsset},
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// AddMilestone adds a milestone, numbering it if the number isn't set
+func (s *Server) AddMilestone(milestone Milestone) *Milestone {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if milestone.Number == 0 {
+		milestone.Number = len(s.milestones) + 1
+	}
+	if milestone.State == "" {
+		milestone.State = "open"
+	}
+	s.milestones = append(s.milestones, &milestone)
+
+	return &milestone
+}
+
+// AddIssue adds an issue or pull request, numbering it if the number isn't set
+func (s *Server) AddIssue(issue Issue) *Issue {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if issue.Number == 0 {
+		issue.Number = len(s.issues) + 1
+	}
+	if issue.State == "" {
+		issue.State = "closed"
+	}
+	s.issues = append(s.issues, &issue)
+
+	return &issue
+}
+
+// AddCommit adds a commit tags and releases can point to
+func (s *Server) AddCommit(commit Commit) *Commit {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.commits = append(s.commits, &commit)
+
+	return &commit
+}
+
+// AddTag adds a lightweight tag pointing to a commit
+func (s *Server) AddTag(name, sha string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.tags[name] = sha
+}
+
+// AddRelease adds an existing release
+func (s *Server) AddRelease(release Release) *Release {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	release.ID = s.newID()
+	s.releases = append(s.releases, &release)
+
+	return &release
+}
+
+// FailRequests makes the next requests with the method and path fail with the status code; times 0 makes all of them fail
+func (s *Server) FailRequests(method, path string, statusCode, times int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.failures = append(s.failures, &failure{method: method, path: path, statusCode: statusCode, times: times})
+}
+
+// Milestones returns copies of all milestones
+func (s *Server) Milestones() []Milestone {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	milestones := make([]Milestone, 0, len(s.milestones))
+	for _, m := range s.milestones {
+		milestones = append(milestones, *m)
+	}
+	return milestones
+}
+
+// Issues returns copies of all issues and pull requests
+func (s *Server) Issues() []Issue {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	issues := make([]Issue, 0, len(s.issues))
+	for _, i := range s.issues {
+		issues = append(issues, *i)
+	}
+	return issues
+}
+
+// Comments returns copies of all comments
+func (s *Server) Comments() []Comment {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	comments := make([]Comment, 0, len(s.comments))
+	for _, c := range s.comments {
+		comments = append(comments, *c)
+	}
+	return comments
+}
+
+// Releases returns copies of all releases
+func (s *Server) Releases() []Release {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	releases := make([]Release, 0, len(s.releases))
+	for _, r := range s.releases {
+		releases = append(releases, *r)
+	}
+	return releases
+}
+
+// Tags returns the tags with the sha they point to
+func (s *Server) Tags() map[string]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tags := map[string]string{}
+	for name, sha := range s.tags {
+		tags[name] = sha
+	}
+	return tags
+}
+
+// Requests returns the method and path of all requests in the order they were received
+func (s *Server) Requests() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]string{}, s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests = append(s.requests, fmt.Sprintf("%v %v", r.Method, r.URL.Path))
+
+	for _, f := range s.failures {
+		if f.method == r.Method && f.path == r.URL.Path && f.times >= 0 {
+			if f.times == 1 {
+				f.times = -1
+			} else if f.times > 1 {
+				f.times--
+			}
+			writeError(w, f.statusCode, "Injected failure", "")
+			return
+		}
+	}
+
+	if r.Header.Get("Authorization") == "" {
+		writeError(w, http.StatusUnauthorized, "Requires authentication", "")
+		return
+	}
+
+	for _, route := range s.routes {
+		if route.method != r.Method {
+			continue
+		}
+		if matches := route.pattern.FindStringSubmatch(r.URL.Path); matches != nil {
+			route.handler(w, r, matches[1:])
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "Not Found", "")
+}
+
+func (s *Server) getRoot(w http.ResponseWriter, r *http.Request, params []string) {
+	w.Header().Set("X-OAuth-Scopes", s.Scopes)
+	writeJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+func (s *Server) getRepository(w http.ResponseWriter, r *http.Request, params []string) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name":           s.repo,
+		"full_name":      fmt.Sprintf("%v/%v", s.owner, s.repo),
+		"html_url":       s.htmlURL(""),
+		"default_branch": "master",
+	})
+}
+
+func (s *Server) listMilestones(w http.ResponseWriter, r *http.Request, params []string) {
+	state := r.URL.Query().Get("state")
+	items := make([]interface{}, 0)
+	for _, m := range s.milestones {
+		if state == "all" || m.State == state || (state == "" && m.State == "open") {
+			items = append(items, s.milestoneJSON(m))
+		}
+	}
+	s.writePage(w, r, items)
+}
+
+func (s *Server) createMilestone(w http.ResponseWriter, r *http.Request, params []string) {
+	var request struct {
+		Title       string `json:"title"`
+		State       string `json:"state"`
+		Description string `json:"description"`
+	}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	for _, m := range s.milestones {
+		if m.Title == request.Title {
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed", "already_exists")
+			return
+		}
+	}
+	milestone := &Milestone{Number: len(s.milestones) + 1, Title: request.Title, State: request.State, Description: request.Description}
+	if milestone.State == "" {
+		milestone.State = "open"
+	}
+	s.milestones = append(s.milestones, milestone)
+	writeJSON(w, http.StatusCreated, s.milestoneJSON(milestone))
+}
+
+func (s *Server) updateMilestone(w http.ResponseWriter, r *http.Request, params []string) {
+	milestone := s.findMilestone(params[0])
+	if milestone == nil {
+		writeError(w, http.StatusNotFound, "Not Found", "")
+		return
+	}
+	var request struct {
+		Title       *string `json:"title"`
+		State       *string `json:"state"`
+		Description *string `json:"description"`
+	}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	if request.Title != nil && *request.Title != "" {
+		milestone.Title = *request.Title
+	}
+	if request.State != nil && *request.State != "" {
+		milestone.State = *request.State
+	}
+	if request.Description != nil {
+		milestone.Description = *request.Description
+	}
+	writeJSON(w, http.StatusOK, s.milestoneJSON(milestone))
+}
+
+func (s *Server) deleteMilestone(w http.ResponseWriter, r *http.Request, params []string) {
+	for i, m := range s.milestones {
+		if strconv.Itoa(m.Number) == params[0] {
+			s.milestones = append(s.milestones[:i], s.milestones[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found", "")
+}
+
+func (s *Server) listIssues(w http.ResponseWriter, r *http.Request, params []string) {
+	state := r.URL.Query().Get("state")
+	milestone := r.URL.Query().Get("milestone")
+	items := make([]interface{}, 0)
+	for _, i := range s.issues {
+		if state != "all" && i.State != state && !(state == "" && i.State == "open") {
+			continue
+		}
+		if milestone != "" && milestone != "*" && strconv.Itoa(i.Milestone) != milestone {
+			continue
+		}
+		items = append(items, s.issueJSON(i))
+	}
+	s.writePage(w, r, items)
+}
+
+func (s *Server) getIssue(w http.ResponseWriter, r *http.Request, params []string) {
+	issue := s.findIssue(params[0])
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Not Found", "")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.issueJSON(issue))
+}
+
+func (s *Server) updateIssue(w http.ResponseWriter, r *http.Request, params []string) {
+	issue := s.findIssue(params[0])
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Not Found", "")
+		return
+	}
+	var request struct {
+		Milestone *int `json:"milestone"`
+	}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	issue.Milestone = 0
+	if request.Milestone != nil {
+		issue.Milestone = *request.Milestone
+	}
+	writeJSON(w, http.StatusOK, s.issueJSON(issue))
+}
+
+func (s *Server) addLabels(w http.ResponseWriter, r *http.Request, params []string) {
+	issue := s.findIssue(params[0])
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Not Found", "")
+		return
+	}
+	var request struct {
+		Labels []string `json:"labels"`
+	}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	for _, l := range request.Labels {
+		if !contains(issue.Labels, l) {
+			issue.Labels = append(issue.Labels, l)
+		}
+	}
+	writeJSON(w, http.StatusOK, labelsJSON(issue.Labels))
+}
+
+func (s *Server) removeLabel(w http.ResponseWriter, r *http.Request, params []string) {
+	issue := s.findIssue(params[0])
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Not Found", "")
+		return
+	}
+	label, _ := url.PathUnescape(params[1])
+	labels := make([]string, 0)
+	for _, l := range issue.Labels {
+		if l != label {
+			labels = append(labels, l)
+		}
+	}
+	if len(labels) == len(issue.Labels) {
+		writeError(w, http.StatusNotFound, "Label does not exist", "")
+		return
+	}
+	issue.Labels = labels
+	writeJSON(w, http.StatusOK, labelsJSON(issue.Labels))
+}
+
+func (s *Server) listComments(w http.ResponseWriter, r *http.Request, params []string) {
+	items := make([]interface{}, 0)
+	for _, c := range s.comments {
+		if strconv.Itoa(c.IssueNumber) == params[0] {
+			items = append(items, s.commentJSON(c))
+		}
+	}
+	s.writePage(w, r, items)
+}
+
+func (s *Server) createComment(w http.ResponseWriter, r *http.Request, params []string) {
+	issue := s.findIssue(params[0])
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Not Found", "")
+		return
+	}
+	var request struct {
+		Body string `json:"body"`
+	}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	comment := &Comment{ID: s.newID(), IssueNumber: issue.Number, Body: request.Body}
+	s.comments = append(s.comments, comment)
+	writeJSON(w, http.StatusCreated, s.commentJSON(comment))
+}
+
+func (s *Server) deleteComment(w http.ResponseWriter, r *http.Request, params []string) {
+	for i, c := range s.comments {
+		if strconv.Itoa(c.ID) == params[0] {
+			s.comments = append(s.comments[:i], s.comments[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found", "")
+}
+
+func (s *Server) listTags(w http.ResponseWriter, r *http.Request, params []string) {
+	items := make([]interface{}, 0)
+	for _, name := range sortedKeys(s.tags) {
+		items = append(items, map[string]interface{}{
+			"name":   name,
+			"commit": map[string]interface{}{"sha": s.commitSHA(s.tags[name])},
+		})
+	}
+	s.writePage(w, r, items)
+}
+
+func (s *Server) getCommit(w http.ResponseWriter, r *http.Request, params []string) {
+	commit := s.findCommit(params[0])
+	if commit == nil {
+		writeError(w, http.StatusNotFound, "No commit found for SHA: "+params[0], "")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"sha":      commit.SHA,
+		"html_url": s.htmlURL("/commit/" + commit.SHA),
+		"commit": map[string]interface{}{
+			"message":   commit.Message,
+			"author":    map[string]interface{}{"name": "estafette", "email": "estafette@example.com", "date": commit.Date},
+			"committer": map[string]interface{}{"name": "estafette", "email": "estafette@example.com", "date": commit.Date},
+		},
+	})
+}
+
+func (s *Server) getCombinedStatus(w http.ResponseWriter, r *http.Request, params []string) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"state":    "success",
+		"sha":      params[0],
+		"statuses": []interface{}{},
+	})
+}
+
+func (s *Server) listCheckRuns(w http.ResponseWriter, r *http.Request, params []string) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"total_count": 0,
+		"check_runs":  []interface{}{},
+	})
+}
+
+func (s *Server) listPullRequestsForCommit(w http.ResponseWriter, r *http.Request, params []string) {
+	writeJSON(w, http.StatusOK, []interface{}{})
+}
+
+func (s *Server) getTagRef(w http.ResponseWriter, r *http.Request, params []string) {
+	sha, ok := s.tags[params[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found", "")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.tagRefJSON(params[0], sha))
+}
+
+func (s *Server) createRef(w http.ResponseWriter, r *http.Request, params []string) {
+	var request struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	if !strings.HasPrefix(request.Ref, "refs/tags/") {
+		writeError(w, http.StatusUnprocessableEntity, "Only tag refs are supported by the fake", "invalid")
+		return
+	}
+	name := strings.TrimPrefix(request.Ref, "refs/tags/")
+	if _, ok := s.tags[name]; ok {
+		writeError(w, http.StatusUnprocessableEntity, "Reference already exists", "already_exists")
+		return
+	}
+	s.tags[name] = request.SHA
+	writeJSON(w, http.StatusCreated, s.tagRefJSON(name, request.SHA))
+}
+
+func (s *Server) updateTagRef(w http.ResponseWriter, r *http.Request, params []string) {
+	if _, ok := s.tags[params[0]]; !ok {
+		writeError(w, http.StatusUnprocessableEntity, "Reference does not exist", "")
+		return
+	}
+	var request struct {
+		SHA string `json:"sha"`
+	}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	s.tags[params[0]] = request.SHA
+	writeJSON(w, http.StatusOK, s.tagRefJSON(params[0], request.SHA))
+}
+
+func (s *Server) deleteTagRef(w http.ResponseWriter, r *http.Request, params []string) {
+	if _, ok := s.tags[params[0]]; !ok {
+		writeError(w, http.StatusUnprocessableEntity, "Reference does not exist", "")
+		return
+	}
+	delete(s.tags, params[0])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getTagObject(w http.ResponseWriter, r *http.Request, params []string) {
+	for _, t := range s.tagObjects {
+		if t.SHA == params[0] {
+			writeJSON(w, http.StatusOK, s.tagObjectJSON(t))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found", "")
+}
+
+func (s *Server) createTagObject(w http.ResponseWriter, r *http.Request, params []string) {
+	var request struct {
+		Tag     string `json:"tag"`
+		Message string `json:"message"`
+		Object  string `json:"object"`
+	}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	digest := sha1.Sum([]byte(request.Tag + request.Message + request.Object))
+	tag := &TagObject{SHA: hex.EncodeToString(digest[:]), Tag: request.Tag, Message: request.Message, ObjectSHA: request.Object}
+	s.tagObjects = append(s.tagObjects, tag)
+	writeJSON(w, http.StatusCreated, s.tagObjectJSON(tag))
+}
+
+func (s *Server) createRelease(w http.ResponseWriter, r *http.Request, params []string) {
+	var request struct {
+		TagName         string `json:"tag_name"`
+		TargetCommitish string `json:"target_commitish"`
+		Name            string `json:"name"`
+		Body            string `json:"body"`
+		Draft           bool   `json:"draft"`
+		PreRelease      bool   `json:"prerelease"`
+	}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	for _, existing := range s.releases {
+		if existing.TagName == request.TagName {
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed", "already_exists")
+			return
+		}
+	}
+
+	// like Github, creating a release for a missing tag creates the tag on the target commitish
+	if _, ok := s.tags[request.TagName]; !ok && !request.Draft {
+		s.tags[request.TagName] = request.TargetCommitish
+	}
+
+	release := &Release{
+		ID:              s.newID(),
+		TagName:         request.TagName,
+		TargetCommitish: request.TargetCommitish,
+		Name:            request.Name,
+		Body:            request.Body,
+		Draft:           request.Draft,
+		PreRelease:      request.PreRelease,
+	}
+	s.releases = append(s.releases, release)
+	writeJSON(w, http.StatusCreated, s.releaseJSON(release))
+}
+
+func (s *Server) getReleaseByTag(w http.ResponseWriter, r *http.Request, params []string) {
+	for _, release := range s.releases {
+		if release.TagName == params[0] {
+			writeJSON(w, http.StatusOK, s.releaseJSON(release))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found", "")
+}
+
+func (s *Server) deleteRelease(w http.ResponseWriter, r *http.Request, params []string) {
+	for i, release := range s.releases {
+		if strconv.Itoa(release.ID) == params[0] {
+			s.releases = append(s.releases[:i], s.releases[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found", "")
+}
+
+func (s *Server) uploadAsset(w http.ResponseWriter, r *http.Request, params []string) {
+	var release *Release
+	for _, existing := range s.releases {
+		if strconv.Itoa(existing.ID) == params[0] {
+			release = existing
+		}
+	}
+	if release == nil {
+		writeError(w, http.StatusNotFound, "Not Found", "")
+		return
+	}
+	name := r.URL.Query().Get("name")
+	for _, a := range release.Assets {
+		if a.Name == name {
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed", "already_exists")
+			return
+		}
+	}
+	content, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "")
+		return
+	}
+	asset := &Asset{
+		ID:          s.newID(),
+		Name:        name,
+		Label:       r.URL.Query().Get("label"),
+		ContentType: r.Header.Get("Content-Type"),
+		Content:     content,
+	}
+	release.Assets = append(release.Assets, asset)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"id":                   asset.ID,
+		"name":                 asset.Name,
+		"label":                asset.Label,
+		"content_type":         asset.ContentType,
+		"size":                 len(asset.Content),
+		"browser_download_url": s.htmlURL(fmt.Sprintf("/releases/download/%v/%v", release.TagName, asset.Name)),
+	})
+}
+
+// writePage writes one page of the items and links to the next page like Github does, see https://developer.github.com/v3/#pagination
+func (s *Server) writePage(w http.ResponseWriter, r *http.Request, items []interface{}) {
+	perPage := s.PageSize
+	if requested, err := strconv.Atoi(r.URL.Query().Get("per_page")); err == nil && requested > 0 && requested < perPage {
+		perPage = requested
+	}
+	page := 1
+	if requested, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && requested > 0 {
+		page = requested
+	}
+
+	start := (page - 1) * perPage
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + perPage
+	if end > len(items) {
+		end = len(items)
+	}
+
+	if end < len(items) {
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(page+1))
+		w.Header().Set("Link", fmt.Sprintf(`<%v%v?%v>; rel="next"`, s.URL, r.URL.Path, query.Encode()))
+	}
+
+	writeJSON(w, http.StatusOK, items[start:end])
+}
+
+func (s *Server) newID() int {
+	id := s.nextID
+	s.nextID++
+	return id
+}
+
+func (s *Server) htmlURL(path string) string {
+	return fmt.Sprintf("%v/%v/%v%v", s.URL, s.owner, s.repo, path)
+}
+
+func (s *Server) findMilestone(number string) *Milestone {
+	for _, m := range s.milestones {
+		if strconv.Itoa(m.Number) == number {
+			return m
+		}
+	}
+	return nil
+}
+
+func (s *Server) findIssue(number string) *Issue {
+	for _, i := range s.issues {
+		if strconv.Itoa(i.Number) == number {
+			return i
+		}
+	}
+	return nil
+}
+
+func (s *Server) findCommit(sha string) *Commit {
+	for _, c := range s.commits {
+		if c.SHA == sha {
+			return c
+		}
+	}
+	return nil
+}
+
+// commitSHA resolves a sha of an annotated tag to the commit it points to
+func (s *Server) commitSHA(sha string) string {
+	for _, t := range s.tagObjects {
+		if t.SHA == sha {
+			return t.ObjectSHA
+		}
+	}
+	return sha
+}
+
+func (s *Server) milestoneJSON(milestone *Milestone) map[string]interface{} {
+	openIssues, closedIssues := 0, 0
+	for _, i := range s.issues {
+		if i.Milestone == milestone.Number && i.State == "open" {
+			openIssues++
+		} else if i.Milestone == milestone.Number {
+			closedIssues++
+		}
+	}
+	return map[string]interface{}{
+		"id":            milestone.Number,
+		"number":        milestone.Number,
+		"title":         milestone.Title,
+		"state":         milestone.State,
+		"description":   milestone.Description,
+		"open_issues":   openIssues,
+		"closed_issues": closedIssues,
+		"html_url":      s.htmlURL(fmt.Sprintf("/milestone/%v", milestone.Number)),
+	}
+}
+
+func (s *Server) issueJSON(issue *Issue) map[string]interface{} {
+	item := map[string]interface{}{
+		"id":       issue.Number,
+		"number":   issue.Number,
+		"title":    issue.Title,
+		"state":    issue.State,
+		"body":     issue.Body,
+		"html_url": s.htmlURL(fmt.Sprintf("/issues/%v", issue.Number)),
+		"labels":   labelsJSON(issue.Labels),
+	}
+	if issue.User != "" {
+		item["user"] = map[string]interface{}{"login": issue.User, "html_url": fmt.Sprintf("%v/%v", s.URL, issue.User)}
+	}
+	if issue.Assignee != "" {
+		item["assignee"] = map[string]interface{}{"login": issue.Assignee, "html_url": fmt.Sprintf("%v/%v", s.URL, issue.Assignee)}
+	}
+	if milestone := s.findMilestone(strconv.Itoa(issue.Milestone)); milestone != nil {
+		item["milestone"] = s.milestoneJSON(milestone)
+	}
+	if issue.IsPullRequest {
+		item["html_url"] = s.htmlURL(fmt.Sprintf("/pull/%v", issue.Number))
+		item["pull_request"] = map[string]interface{}{
+			"url":      fmt.Sprintf("%v/repos/%v/%v/pulls/%v", s.URL, s.owner, s.repo, issue.Number),
+			"html_url": s.htmlURL(fmt.Sprintf("/pull/%v", issue.Number)),
+		}
+	}
+	return item
+}
+
+func (s *Server) commentJSON(comment *Comment) map[string]interface{} {
+	return map[string]interface{}{
+		"id":       comment.ID,
+		"body":     comment.Body,
+		"html_url": s.htmlURL(fmt.Sprintf("/issues/%v#issuecomment-%v", comment.IssueNumber, comment.ID)),
+	}
+}
+
+func (s *Server) tagRefJSON(name, sha string) map[string]interface{} {
+	objectType := "commit"
+	for _, t := range s.tagObjects {
+		if t.SHA == sha {
+			objectType = "tag"
+		}
+	}
+	return map[string]interface{}{
+		"ref":    "refs/tags/" + name,
+		"object": map[string]interface{}{"sha": sha, "type": objectType},
+	}
+}
+
+func (s *Server) tagObjectJSON(tag *TagObject) map[string]interface{} {
+	return map[string]interface{}{
+		"sha":     tag.SHA,
+		"tag":     tag.Tag,
+		"message": tag.Message,
+		"object":  map[string]interface{}{"sha": tag.ObjectSHA, "type": "commit"},
+	}
+}
+
+func (s *Server) releaseJSON(release *Release) map[string]interface{} {
+	return map[string]interface{}{
+		"id":               release.ID,
+		"tag_name":         release.TagName,
+		"target_commitish": release.TargetCommitish,
+		"name":             release.Name,
+		"body":             release.Body,
+		"draft":            release.Draft,
+		"prerelease":       release.PreRelease,
+		"html_url":         s.htmlURL("/releases/tag/" + release.TagName),
+		"upload_url":       fmt.Sprintf("%v/uploads/repos/%v/%v/releases/%v/assets{?name,label}", s.URL, s.owner, s.repo, release.ID),
+	}
+}
+
+func labelsJSON(labels []string) []interface{} {
+	items := make([]interface{}, 0, len(labels))
+	for _, l := range labels {
+		items = append(items, map[string]interface{}{"name": l})
+	}
+	return items
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON", "")
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error body in the format Github uses, see https://developer.github.com/v3/#client-errors
+func writeError(w http.ResponseWriter, statusCode int, message, code string) {
+	body := map[string]interface{}{
+		"message":           message,
+		"documentation_url": "https://docs.github.com/rest",
+	}
+	if code != "" {
+		body["errors"] = []interface{}{map[string]interface{}{"code": code}}
+	}
+	writeJSON(w, statusCode, body)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,109 @@
+package fakegithub
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func get(t *testing.T, url string) *http.Response {
+	request, _ := http.NewRequest("GET", url, nil)
+	request.Header.Set("Authorization", "token secret")
+	response, err := http.DefaultClient.Do(request)
+	assert.Nil(t, err)
+	return response
+}
+
+func TestServer(t *testing.T) {
+
+	t.Run("PaginatesListsWithLinkHeader", func(t *testing.T) {
+
+		server := NewServer("estafette", "app")
+		defer server.Close()
+		server.PageSize = 2
+		for i := 0; i < 3; i++ {
+			server.AddMilestone(Milestone{Title: "1.0." + string('0'+rune(i))})
+		}
+
+		// act
+		response := get(t, server.URL+"/repos/estafette/app/milestones?state=open&per_page=100")
+
+		var milestones []map[string]interface{}
+		json.NewDecoder(response.Body).Decode(&milestones)
+		assert.Equal(t, 2, len(milestones))
+		assert.True(t, strings.Contains(response.Header.Get("Link"), `page=2`))
+
+		next := strings.Trim(strings.Split(response.Header.Get("Link"), ";")[0], "<>")
+		response = get(t, next)
+		json.NewDecoder(response.Body).Decode(&milestones)
+		assert.Equal(t, 1, len(milestones))
+		assert.Equal(t, "", response.Header.Get("Link"))
+	})
+
+	t.Run("InjectsFailuresTheGivenNumberOfTimes", func(t *testing.T) {
+
+		server := NewServer("estafette", "app")
+		defer server.Close()
+		server.FailRequests("GET", "/repos/estafette/app", http.StatusBadGateway, 2)
+
+		// act
+		first := get(t, server.URL+"/repos/estafette/app")
+		second := get(t, server.URL+"/repos/estafette/app")
+		third := get(t, server.URL+"/repos/estafette/app")
+
+		assert.Equal(t, http.StatusBadGateway, first.StatusCode)
+		assert.Equal(t, http.StatusBadGateway, second.StatusCode)
+		assert.Equal(t, http.StatusOK, third.StatusCode)
+	})
+
+	t.Run("ReturnsNotFoundForOtherRepositories", func(t *testing.T) {
+
+		server := NewServer("estafette", "app")
+		defer server.Close()
+
+		// act
+		response := get(t, server.URL+"/repos/estafette/other")
+
+		assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	})
+
+	t.Run("RequiresAuthentication", func(t *testing.T) {
+
+		server := NewServer("estafette", "app")
+		defer server.Close()
+
+		// act
+		response, err := http.Get(server.URL + "/repos/estafette/app")
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	})
+
+	t.Run("ReturnsAlreadyExistsForDuplicateRelease", func(t *testing.T) {
+
+		server := NewServer("estafette", "app")
+		defer server.Close()
+		server.AddRelease(Release{TagName: "v1.0.0"})
+
+		request, _ := http.NewRequest("POST", server.URL+"/repos/estafette/app/releases", strings.NewReader(`{"tag_name":"v1.0.0"}`))
+		request.Header.Set("Authorization", "token secret")
+
+		// act
+		response, err := http.DefaultClient.Do(request)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, response.StatusCode)
+		var body struct {
+			Errors []struct {
+				Code string `json:"code"`
+			} `json:"errors"`
+		}
+		json.NewDecoder(response.Body).Decode(&body)
+		if assert.Equal(t, 1, len(body.Errors)) {
+			assert.Equal(t, "already_exists", body.Errors[0].Code)
+		}
+	})
+}
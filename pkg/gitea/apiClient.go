@@ -0,0 +1,340 @@
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/estafette/estafette-extension-github-release/pkg/releasenotes"
+	"github.com/rs/zerolog/log"
+)
+
+// APIClient allows to communicate with the Gitea api, it implements the release.Provider interface
+type APIClient interface {
+	Name() string
+	ChangeRequestsName() string
+	GetMilestoneByVersion(ctx context.Context, repoOwner, repoName, version string, lookup github.MilestoneLookup) (milestone *releasenotes.Milestone, err error)
+	GetIssuesAndChangeRequestsForMilestone(ctx context.Context, repoOwner, repoName string, milestone releasenotes.Milestone) (issues []*releasenotes.Issue, changeRequests []*releasenotes.ChangeRequest, err error)
+	CreateRelease(ctx context.Context, repoOwner, repoName string, release releasenotes.Release) (createdRelease *releasenotes.Release, err error)
+	UploadReleaseAsset(ctx context.Context, repoOwner, repoName string, release releasenotes.Release, name, label, contentType string, content []byte) (uploadedAsset *releasenotes.ReleaseAsset, err error)
+	CloseMilestone(ctx context.Context, repoOwner, repoName string, milestone releasenotes.Milestone) (err error)
+}
+
+type apiClientImpl struct {
+	baseURL        string
+	accessToken    string
+	requestTimeout time.Duration
+	client         *http.Client
+}
+
+// NewAPIClient creates a client for the Gitea api at the base url of the options, like https://gitea.example.com/api/v1, sending requests through the middleware of the Github client
+func NewAPIClient(options github.Options) APIClient {
+	return &apiClientImpl{
+		baseURL:        strings.TrimSuffix(options.BaseURL, "/"),
+		accessToken:    options.AccessToken,
+		requestTimeout: options.RequestTimeout,
+		client:         github.NewProviderHTTPClient(options),
+	}
+}
+
+func (gt *apiClientImpl) Name() string {
+	return "gitea"
+}
+
+func (gt *apiClientImpl) ChangeRequestsName() string {
+	return "pull requests"
+}
+
+func (gt *apiClientImpl) GetMilestoneByVersion(ctx context.Context, repoOwner, repoName, version string, lookup github.MilestoneLookup) (milestone *releasenotes.Milestone, err error) {
+
+	// https://try.gitea.io/api/swagger#/issue/issueGetMilestonesList
+	log.Info().Msgf("Retrieving milestone for version %v with lookup %v...", version, lookup.Describe())
+
+	state := "open"
+	if lookup.IncludeClosed {
+		state = "all"
+	}
+
+	pages, err := gt.callGiteaAPIPaginated(ctx, fmt.Sprintf("%v/repos/%v/%v/milestones?state=%v&limit=50", gt.baseURL, repoOwner, repoName, state))
+	if err != nil {
+		return
+	}
+
+	titles := make([]string, 0)
+	states := make([]string, 0)
+	milestones := make([]*Milestone, 0)
+	for _, page := range pages {
+		var pageMilestones []*Milestone
+		err = json.Unmarshal(page, &pageMilestones)
+		if err != nil {
+			return
+		}
+		for _, m := range pageMilestones {
+			titles = append(titles, m.Title)
+			states = append(states, m.State)
+			milestones = append(milestones, m)
+		}
+	}
+
+	index, err := github.MatchMilestoneTitle(titles, states, version, lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	// gitea's milestones have no web url, so it's derived from the repository's
+	repoURL, err := gt.getRepositoryWebURL(ctx, repoOwner, repoName)
+	if err != nil {
+		return nil, err
+	}
+	milestone = milestones[index].toMilestone(repoURL)
+
+	log.Info().Msgf("Retrieved %v milestone %v", milestone.State, milestone.Title)
+
+	return milestone, nil
+}
+
+func (gt *apiClientImpl) GetIssuesAndChangeRequestsForMilestone(ctx context.Context, repoOwner, repoName string, milestone releasenotes.Milestone) (issues []*releasenotes.Issue, changeRequests []*releasenotes.ChangeRequest, err error) {
+
+	// https://try.gitea.io/api/swagger#/issue/issueListIssues
+	log.Info().Msgf("Retrieving issues for milestone %v...", milestone.Title)
+
+	query := url.Values{}
+	query.Set("state", "closed")
+	query.Set("milestones", milestone.Title)
+	query.Set("limit", "50")
+
+	pages, err := gt.callGiteaAPIPaginated(ctx, fmt.Sprintf("%v/repos/%v/%v/issues?%v", gt.baseURL, repoOwner, repoName, query.Encode()))
+	if err != nil {
+		return
+	}
+
+	// the issues endpoint returns pull requests as well
+	issues = make([]*releasenotes.Issue, 0)
+	changeRequests = make([]*releasenotes.ChangeRequest, 0)
+	for _, page := range pages {
+		var pageIssues []*Issue
+		err = json.Unmarshal(page, &pageIssues)
+		if err != nil {
+			return
+		}
+		for _, i := range pageIssues {
+			if i.PullRequest == nil {
+				issues = append(issues, i.toIssue())
+			} else if i.PullRequest.Merged {
+				changeRequests = append(changeRequests, i.toChangeRequest())
+			}
+		}
+	}
+
+	log.Info().Msgf("Retrieved %v issues and %v pull requests", len(issues), len(changeRequests))
+
+	return issues, changeRequests, nil
+}
+
+func (gt *apiClientImpl) CreateRelease(ctx context.Context, repoOwner, repoName string, release releasenotes.Release) (createdRelease *releasenotes.Release, err error) {
+
+	// https://try.gitea.io/api/swagger#/repository/repoCreateRelease
+	log.Info().Msgf("Creating release %v...", release.Name)
+
+	createRequest := Release{
+		TagName:         release.TagName,
+		TargetCommitish: release.TargetCommitish,
+		Name:            release.Name,
+		Body:            release.Body,
+		Draft:           release.Draft,
+		PreRelease:      release.PreRelease,
+	}
+
+	body, err := gt.callGiteaAPI(ctx, "POST", fmt.Sprintf("%v/repos/%v/%v/releases", gt.baseURL, repoOwner, repoName), "application/json", []int{http.StatusCreated, http.StatusConflict}, createRequest)
+	if err != nil {
+		return
+	}
+
+	var created Release
+	err = json.Unmarshal(body, &created)
+	if err != nil {
+		return
+	}
+	if created.ID == 0 {
+		log.Info().Msg("Release already exist, skipping")
+		return nil, nil
+	}
+
+	log.Info().Msg("Created release")
+
+	createdRelease = &release
+	createdRelease.ID = created.ID
+	createdRelease.WebURL = created.HTMLURL
+
+	return createdRelease, nil
+}
+
+func (gt *apiClientImpl) UploadReleaseAsset(ctx context.Context, repoOwner, repoName string, release releasenotes.Release, name, label, contentType string, content []byte) (uploadedAsset *releasenotes.ReleaseAsset, err error) {
+
+	// https://try.gitea.io/api/swagger#/repository/repoCreateReleaseAttachment
+	log.Info().Msgf("Uploading release asset %v...", name)
+
+	var requestBody bytes.Buffer
+	writer := multipart.NewWriter(&requestBody)
+	part, err := writer.CreateFormFile("attachment", name)
+	if err != nil {
+		return
+	}
+	_, err = part.Write(content)
+	if err != nil {
+		return
+	}
+	err = writer.Close()
+	if err != nil {
+		return
+	}
+
+	body, _, err := github.CallProviderAPI(ctx, gt.client, gt.requestTimeout, "POST", fmt.Sprintf("%v/repos/%v/%v/releases/%v/assets?name=%v", gt.baseURL, repoOwner, repoName, release.ID, url.QueryEscape(name)), gt.headers(), writer.FormDataContentType(), []int{http.StatusCreated}, requestBody.Bytes())
+	if err != nil {
+		return
+	}
+
+	var attachment Attachment
+	err = json.Unmarshal(body, &attachment)
+	if err != nil {
+		return
+	}
+
+	log.Info().Msgf("Uploaded release asset %v", attachment.Name)
+
+	// gitea has no labels for attachments
+	return &releasenotes.ReleaseAsset{
+		Name:        attachment.Name,
+		ContentType: contentType,
+		Size:        attachment.Size,
+		DownloadURL: attachment.BrowserDownloadURL,
+	}, nil
+}
+
+func (gt *apiClientImpl) CloseMilestone(ctx context.Context, repoOwner, repoName string, milestone releasenotes.Milestone) (err error) {
+
+	// https://try.gitea.io/api/swagger#/issue/issueEditMilestone
+	log.Info().Msgf("Closing milestone %v...", milestone.Title)
+
+	_, err = gt.callGiteaAPI(ctx, "PATCH", fmt.Sprintf("%v/repos/%v/%v/milestones/%v", gt.baseURL, repoOwner, repoName, milestone.ID), "application/json", []int{http.StatusOK}, MilestoneUpdateRequest{State: "closed"})
+	if err != nil {
+		return
+	}
+
+	log.Info().Msg("Closed milestone")
+
+	return nil
+}
+
+// getRepositoryWebURL returns the url of the repository in the gitea ui as reported by the api, since the api base url doesn't tell where the ui is hosted
+func (gt *apiClientImpl) getRepositoryWebURL(ctx context.Context, repoOwner, repoName string) (webURL string, err error) {
+
+	// https://try.gitea.io/api/swagger#/repository/repoGet
+	body, err := gt.callGiteaAPI(ctx, "GET", fmt.Sprintf("%v/repos/%v/%v", gt.baseURL, repoOwner, repoName), "", []int{http.StatusOK}, nil)
+	if err != nil {
+		return
+	}
+
+	var repository Repository
+	err = json.Unmarshal(body, &repository)
+	if err != nil {
+		return
+	}
+
+	return strings.TrimSuffix(repository.HTMLURL, "/"), nil
+}
+
+func (gt *apiClientImpl) headers() map[string]string {
+	return map[string]string{
+		"Authorization": fmt.Sprintf("%v %v", "token", gt.accessToken),
+	}
+}
+
+// callGiteaAPIPaginated retrieves all pages of a list by following the next links in the Link header
+func (gt *apiClientImpl) callGiteaAPIPaginated(ctx context.Context, url string) (pages [][]byte, err error) {
+
+	for url != "" {
+		body, header, err := github.CallProviderAPI(ctx, gt.client, gt.requestTimeout, "GET", url, gt.headers(), "", []int{http.StatusOK}, nil)
+		if err != nil {
+			return pages, err
+		}
+		pages = append(pages, body)
+		url = github.GetNextPageURL(header.Get("Link"))
+	}
+
+	return pages, nil
+}
+
+func (gt *apiClientImpl) callGiteaAPI(ctx context.Context, method, url, contentType string, validStatusCodes []int, params interface{}) (body []byte, err error) {
+
+	var requestBody []byte
+	if params != nil {
+		requestBody, err = json.Marshal(params)
+		if err != nil {
+			return
+		}
+	}
+
+	body, _, err = github.CallProviderAPI(ctx, gt.client, gt.requestTimeout, method, url, gt.headers(), contentType, validStatusCodes, requestBody)
+
+	return
+}
+
+func (milestone *Milestone) toMilestone(repoURL string) *releasenotes.Milestone {
+	return &releasenotes.Milestone{
+		ID:          milestone.ID,
+		Title:       milestone.Title,
+		State:       milestone.State,
+		Description: milestone.Description,
+		WebURL:      fmt.Sprintf("%v/milestone/%v", repoURL, milestone.ID),
+	}
+}
+
+func (issue *Issue) toIssue() *releasenotes.Issue {
+	return &releasenotes.Issue{
+		Number:    issue.Number,
+		Reference: fmt.Sprintf("#%v", issue.Number),
+		Title:     issue.Title,
+		State:     issue.State,
+		WebURL:    issue.HTMLURL,
+		Assignee:  issue.Assignee.toUser(),
+		Labels:    issue.labelNames(),
+	}
+}
+
+func (issue *Issue) toChangeRequest() *releasenotes.ChangeRequest {
+	return &releasenotes.ChangeRequest{
+		Number:    issue.Number,
+		Reference: fmt.Sprintf("#%v", issue.Number),
+		Title:     issue.Title,
+		State:     issue.State,
+		WebURL:    issue.HTMLURL,
+		Merged:    issue.PullRequest != nil && issue.PullRequest.Merged,
+		Assignee:  issue.Assignee.toUser(),
+		Labels:    issue.labelNames(),
+	}
+}
+
+func (issue *Issue) labelNames() []string {
+	names := make([]string, 0, len(issue.Labels))
+	for _, l := range issue.Labels {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+func (user *User) toUser() *releasenotes.User {
+	if user == nil {
+		return nil
+	}
+	return &releasenotes.User{
+		Login: user.Login,
+	}
+}
@@ -0,0 +1,111 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/estafette/estafette-extension-github-release/pkg/releasenotes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIClient(t *testing.T) {
+
+	t.Run("GetMilestoneByVersionLinksToMilestoneInRepository", func(t *testing.T) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+			switch r.URL.Path {
+			case "/gitea/api/v1/repos/estafette/app/milestones":
+				fmt.Fprint(w, `[{"id":5,"title":"1.2.0","state":"open"}]`)
+			case "/gitea/api/v1/repos/estafette/app":
+				fmt.Fprint(w, `{"id":2,"html_url":"https://git.example.com/gitea/estafette/app"}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		client := NewAPIClient(github.Options{BaseURL: server.URL + "/gitea/api/v1", AccessToken: "secret", RequestTimeout: time.Minute})
+
+		// act
+		milestone, err := client.GetMilestoneByVersion(context.Background(), "estafette", "app", "1.2.0", github.MilestoneLookup{Strategy: github.MilestoneLookupExact})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 5, milestone.ID)
+		assert.Equal(t, "https://git.example.com/gitea/estafette/app/milestone/5", milestone.WebURL)
+	})
+
+	t.Run("GetIssuesAndChangeRequestsForMilestoneSeparatesMergedPullRequests", func(t *testing.T) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "1.2.0", r.URL.Query().Get("milestones"))
+			assert.Equal(t, "closed", r.URL.Query().Get("state"))
+			fmt.Fprint(w, `[{"number":3,"title":"Fix crash","state":"closed","labels":[{"name":"bug"}]},{"number":4,"title":"Add retries","state":"closed","pull_request":{"merged":true}},{"number":5,"title":"Abandoned","state":"closed","pull_request":{"merged":false}}]`)
+		}))
+		defer server.Close()
+
+		client := NewAPIClient(github.Options{BaseURL: server.URL + "/api/v1", AccessToken: "secret", RequestTimeout: time.Minute})
+
+		// act
+		issues, pullRequests, err := client.GetIssuesAndChangeRequestsForMilestone(context.Background(), "estafette", "app", releasenotes.Milestone{ID: 5, Title: "1.2.0"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(issues))
+		assert.Equal(t, []string{"bug"}, issues[0].Labels)
+		assert.Equal(t, 1, len(pullRequests))
+		assert.Equal(t, "#4", pullRequests[0].Reference)
+	})
+
+	t.Run("CreateReleaseReturnsReleaseWithID", func(t *testing.T) {
+
+		var createRequest Release
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/v1/repos/estafette/app/releases", r.URL.Path)
+			body, _ := ioutil.ReadAll(r.Body)
+			_ = json.Unmarshal(body, &createRequest)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id":9,"tag_name":"v1.2.0","html_url":"https://gitea.example.com/estafette/app/releases/tag/v1.2.0"}`)
+		}))
+		defer server.Close()
+
+		client := NewAPIClient(github.Options{BaseURL: server.URL + "/api/v1", AccessToken: "secret", RequestTimeout: time.Minute})
+
+		// act
+		release, err := client.CreateRelease(context.Background(), "estafette", "app", releasenotes.Release{TagName: "v1.2.0", TargetCommitish: "abc", Name: "App v1.2.0", PreRelease: true})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 9, release.ID)
+		assert.Equal(t, "https://gitea.example.com/estafette/app/releases/tag/v1.2.0", release.WebURL)
+		assert.Equal(t, "abc", createRequest.TargetCommitish)
+		assert.True(t, createRequest.PreRelease)
+	})
+
+	t.Run("UploadReleaseAssetSendsAttachment", func(t *testing.T) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/v1/repos/estafette/app/releases/9/assets", r.URL.Path)
+			assert.Equal(t, "app.zip", r.URL.Query().Get("name"))
+			_, header, err := r.FormFile("attachment")
+			assert.Nil(t, err)
+			assert.Equal(t, "app.zip", header.Filename)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id":1,"name":"app.zip","size":11,"browser_download_url":"https://gitea.example.com/attachments/1"}`)
+		}))
+		defer server.Close()
+
+		client := NewAPIClient(github.Options{BaseURL: server.URL + "/api/v1", AccessToken: "secret", RequestTimeout: time.Minute})
+
+		// act
+		asset, err := client.UploadReleaseAsset(context.Background(), "estafette", "app", releasenotes.Release{ID: 9, TagName: "v1.2.0"}, "app.zip", "", "application/zip", []byte("zip content"))
+
+		assert.Nil(t, err)
+		assert.Equal(t, "https://gitea.example.com/attachments/1", asset.DownloadURL)
+	})
+}
@@ -0,0 +1,62 @@
+package gitea
+
+type Repository struct {
+	ID      int    `json:"id"`
+	HTMLURL string `json:"html_url"`
+}
+
+type Milestone struct {
+	ID           int    `json:"id"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	State        string `json:"state"`
+	OpenIssues   int    `json:"open_issues"`
+	ClosedIssues int    `json:"closed_issues"`
+}
+
+type MilestoneUpdateRequest struct {
+	State string `json:"state"`
+}
+
+type Issue struct {
+	ID          int               `json:"id"`
+	Number      int               `json:"number"`
+	Title       string            `json:"title"`
+	State       string            `json:"state"`
+	HTMLURL     string            `json:"html_url"`
+	Assignee    *User             `json:"assignee"`
+	Labels      []*Label          `json:"labels"`
+	PullRequest *IssuePullRequest `json:"pull_request"`
+}
+
+type IssuePullRequest struct {
+	Merged bool `json:"merged"`
+}
+
+type User struct {
+	ID    int    `json:"id"`
+	Login string `json:"login"`
+}
+
+type Label struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type Release struct {
+	ID              int    `json:"id,omitempty"`
+	TagName         string `json:"tag_name"`
+	TargetCommitish string `json:"target_commitish"`
+	Name            string `json:"name"`
+	Body            string `json:"body"`
+	Draft           bool   `json:"draft"`
+	PreRelease      bool   `json:"prerelease"`
+	HTMLURL         string `json:"html_url,omitempty"`
+}
+
+type Attachment struct {
+	ID                 int    `json:"id"`
+	Name               string `json:"name"`
+	Size               int    `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
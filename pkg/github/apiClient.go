@@ -0,0 +1,883 @@
+// Package github is a client for the Github rest and graphql apis with retries, rate limiting, caching and debug tracing as http middleware
+package github
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// APIClient allows to communicate with the Github api
+type APIClient interface {
+	GetMilestoneByVersion(ctx context.Context, repoOwner, repoName, version string, lookup MilestoneLookup) (ms *Milestone, err error)
+	GetMilestones(ctx context.Context, repoOwner, repoName, state string) (milestones []*Milestone, err error)
+	CreateMilestone(ctx context.Context, repoOwner, repoName string, createRequest MilestoneUpdateRequest) (createdMilestone *Milestone, err error)
+	DeleteMilestone(ctx context.Context, repoOwner, repoName string, milestone Milestone) (err error)
+	GetIssuesAndPullRequestsForMilestone(ctx context.Context, repoOwner, repoName string, milestone Milestone) (issues []*Issue, pullRequests []*PullRequest, err error)
+	GetIssuesForMilestone(ctx context.Context, repoOwner, repoName string, milestone Milestone, state string) (issuesAndPullRequests []*Issue, err error)
+	SetIssueMilestone(ctx context.Context, repoOwner, repoName string, issueNumber int, milestoneNumber *int) (err error)
+	AddLabelsToIssue(ctx context.Context, repoOwner, repoName string, issueNumber int, labels []string) (err error)
+	RemoveLabelFromIssue(ctx context.Context, repoOwner, repoName string, issueNumber int, label string) (err error)
+	GetIssueComments(ctx context.Context, repoOwner, repoName string, issueNumber int) (comments []*Comment, err error)
+	CreateIssueComment(ctx context.Context, repoOwner, repoName string, issueNumber int, body string) (comment *Comment, err error)
+	DeleteIssueComment(ctx context.Context, repoOwner, repoName string, comment Comment) (err error)
+	GetRepository(ctx context.Context, repoOwner, repoName string) (repository *Repository, err error)
+	GetCommit(ctx context.Context, repoOwner, repoName, gitRevision string) (commit *Commit, err error)
+	GetCombinedStatus(ctx context.Context, repoOwner, repoName, gitRevision string) (combinedStatus *CombinedStatus, err error)
+	GetCheckRuns(ctx context.Context, repoOwner, repoName, gitRevision string) (checkRuns []*CheckRun, err error)
+	GetIssue(ctx context.Context, repoOwner, repoName string, issueNumber int) (issue *Issue, err error)
+	GetTags(ctx context.Context, repoOwner, repoName string) (tags []*Tag, err error)
+	CompareCommits(ctx context.Context, repoOwner, repoName, base, head string) (comparison *Comparison, err error)
+	GetPullRequestsForCommit(ctx context.Context, repoOwner, repoName, sha string) (pullRequests []*PullRequest, err error)
+	GetTokenScopes(ctx context.Context) (scopes []string, hasScopes bool, err error)
+	GetReleaseByTag(ctx context.Context, repoOwner, repoName, tagName string) (release *Release, err error)
+	PublishRelease(ctx context.Context, repoOwner, repoName string, release Release) (createdRelease *Release, err error)
+	CloseMilestone(ctx context.Context, repoOwner, repoName string, milestone Milestone) (err error)
+	ReopenMilestone(ctx context.Context, repoOwner, repoName string, milestone Milestone) (err error)
+	GetTagRef(ctx context.Context, repoOwner, repoName, tagName string) (ref *Ref, err error)
+	UpdateTagRef(ctx context.Context, repoOwner, repoName, tagName, sha string) (err error)
+	DeleteTagRef(ctx context.Context, repoOwner, repoName, tagName string) (err error)
+	GetTagObject(ctx context.Context, repoOwner, repoName, sha string) (tag *TagObject, err error)
+	CreateTagObject(ctx context.Context, repoOwner, repoName string, createRequest TagCreateRequest) (tag *TagObject, err error)
+	CreateTagRef(ctx context.Context, repoOwner, repoName, tagName, sha string) (err error)
+	DeleteRelease(ctx context.Context, repoOwner, repoName string, release Release) (err error)
+	UploadReleaseAsset(ctx context.Context, createdRelease Release, name, label, contentType string, content []byte) (uploadedAsset *ReleaseAsset, err error)
+}
+
+type apiClientImpl struct {
+	baseURL        string
+	requestTimeout time.Duration
+	client         *http.Client
+}
+
+// Options configure the Github api client and the middleware of its http client
+type Options struct {
+	BaseURL        string
+	AccessToken    string
+	RequestTimeout time.Duration
+	UserAgent      string
+	MaxRetries     int
+	Metrics        *Metrics
+	Cache          *ResponseCache
+	Transport      http.RoundTripper
+	Debug          bool
+	HAR            *HARRecorder
+	Headers        http.Header
+}
+
+// NewAPIClient creates a client for the Github rest api
+func NewAPIClient(options Options) APIClient {
+	return &apiClientImpl{
+		baseURL:        strings.TrimSuffix(options.BaseURL, "/"),
+		requestTimeout: options.RequestTimeout,
+		client:         NewHTTPClient(options),
+	}
+}
+
+// NewHTTPClient creates the http client used for all requests of a run, with each concern handled by a separate middleware; other apis use it with their own Headers instead of Github's to get the same retries, rate limiting, tls and proxy settings, debug tracing and metrics
+func NewHTTPClient(options Options) *http.Client {
+	base := options.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	headers := options.Headers
+	if headers == nil {
+		headers = getGithubHeaders(options.UserAgent)
+	}
+
+	// in debug mode the requests are traced as sent, with all headers, instead of only logging their status
+	middlewares := []middleware{
+		retryMiddleware(options.MaxRetries, exponentialJitterBackoff, options.Metrics),
+		rateLimitMiddleware(time.Now),
+	}
+	if !options.Debug {
+		middlewares = append(middlewares, loggingMiddleware())
+	}
+	middlewares = append(middlewares,
+		metricsMiddleware(options.Metrics),
+		authMiddleware("token", options.AccessToken),
+		headersMiddleware(headers),
+		cacheMiddleware(options.Cache, options.Metrics),
+	)
+	if options.Debug {
+		// below the cache, so traces show the conditional requests and 304 responses actually exchanged with Github
+		middlewares = append(middlewares, debugMiddleware(options.HAR))
+	}
+
+	return &http.Client{
+		Transport: chainMiddleware(base, middlewares...),
+	}
+}
+
+func (gh *apiClientImpl) GetMilestoneByVersion(ctx context.Context, repoOwner, repoName, version string, lookup MilestoneLookup) (ms *Milestone, err error) {
+
+	// https://developer.github.com/v3/issues/milestones/#list-milestones-for-a-repository
+	log.Info().Msgf("Retrieving milestone for version %v with lookup %v...", version, lookup.Describe())
+
+	state := "open"
+	if lookup.IncludeClosed {
+		state = "all"
+	}
+
+	milestones, err := gh.GetMilestones(ctx, repoOwner, repoName, state)
+	if err != nil {
+		return
+	}
+
+	ms, err = findMilestone(milestones, version, lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Msgf("Retrieved %v milestone %v", ms.State, ms.Title)
+
+	return ms, nil
+}
+
+func (gh *apiClientImpl) GetMilestones(ctx context.Context, repoOwner, repoName, state string) (milestones []*Milestone, err error) {
+
+	// https://developer.github.com/v3/issues/milestones/#list-milestones-for-a-repository
+	pages, err := gh.callGithubAPIPaginated(ctx, fmt.Sprintf("%v/repos/%v/%v/milestones?state=%v&per_page=100", gh.baseURL, repoOwner, repoName, state))
+	if err != nil {
+		return
+	}
+
+	milestones = make([]*Milestone, 0)
+	for _, body := range pages {
+		var page []*Milestone
+		err = json.Unmarshal(body, &page)
+		if err != nil {
+			return
+		}
+		milestones = append(milestones, page...)
+	}
+
+	return milestones, nil
+}
+
+func (gh *apiClientImpl) CreateMilestone(ctx context.Context, repoOwner, repoName string, createRequest MilestoneUpdateRequest) (createdMilestone *Milestone, err error) {
+
+	// https://developer.github.com/v3/issues/milestones/#create-a-milestone
+	log.Info().Msgf("Creating milestone %v...", createRequest.Title)
+
+	body, err := gh.callGithubAPI(ctx, "POST", fmt.Sprintf("%v/repos/%v/%v/milestones", gh.baseURL, repoOwner, repoName), "application/json", []int{http.StatusCreated}, createRequest)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(body, &createdMilestone)
+	if err != nil {
+		return
+	}
+
+	log.Info().Msgf("Created milestone #%v %v", createdMilestone.Number, createdMilestone.Title)
+
+	return createdMilestone, nil
+}
+
+func (gh *apiClientImpl) DeleteMilestone(ctx context.Context, repoOwner, repoName string, milestone Milestone) (err error) {
+
+	// https://developer.github.com/v3/issues/milestones/#delete-a-milestone
+	log.Info().Msgf("Deleting milestone #%v...", milestone.Number)
+
+	_, err = gh.callGithubAPI(ctx, "DELETE", fmt.Sprintf("%v/repos/%v/%v/milestones/%v", gh.baseURL, repoOwner, repoName, milestone.Number), "", []int{http.StatusNoContent}, nil)
+	if err != nil {
+		return
+	}
+
+	log.Info().Msg("Deleted milestone")
+
+	return nil
+}
+
+func (gh *apiClientImpl) GetIssuesForMilestone(ctx context.Context, repoOwner, repoName string, milestone Milestone, state string) (issuesAndPullRequests []*Issue, err error) {
+
+	// https://developer.github.com/v3/issues/#list-issues-for-a-repository
+	pages, err := gh.callGithubAPIPaginated(ctx, fmt.Sprintf("%v/repos/%v/%v/issues?state=%v&milestone=%v&per_page=100", gh.baseURL, repoOwner, repoName, state, milestone.Number))
+	if err != nil {
+		return
+	}
+
+	issuesAndPullRequests = make([]*Issue, 0)
+	for _, body := range pages {
+		var page []*Issue
+		err = json.Unmarshal(body, &page)
+		if err != nil {
+			return
+		}
+		issuesAndPullRequests = append(issuesAndPullRequests, page...)
+	}
+
+	return issuesAndPullRequests, nil
+}
+
+func (gh *apiClientImpl) SetIssueMilestone(ctx context.Context, repoOwner, repoName string, issueNumber int, milestoneNumber *int) (err error) {
+
+	// https://developer.github.com/v3/issues/#update-an-issue
+	_, err = gh.callGithubAPI(ctx, "PATCH", fmt.Sprintf("%v/repos/%v/%v/issues/%v", gh.baseURL, repoOwner, repoName, issueNumber), "application/json", []int{http.StatusOK}, IssueMilestoneUpdateRequest{Milestone: milestoneNumber})
+
+	return
+}
+
+func (gh *apiClientImpl) AddLabelsToIssue(ctx context.Context, repoOwner, repoName string, issueNumber int, labels []string) (err error) {
+
+	// https://developer.github.com/v3/issues/labels/#add-labels-to-an-issue
+	_, err = gh.callGithubAPI(ctx, "POST", fmt.Sprintf("%v/repos/%v/%v/issues/%v/labels", gh.baseURL, repoOwner, repoName, issueNumber), "application/json", []int{http.StatusOK}, LabelsRequest{Labels: labels})
+
+	return
+}
+
+func (gh *apiClientImpl) RemoveLabelFromIssue(ctx context.Context, repoOwner, repoName string, issueNumber int, label string) (err error) {
+
+	// https://developer.github.com/v3/issues/labels/#remove-a-label-from-an-issue
+	_, err = gh.callGithubAPI(ctx, "DELETE", fmt.Sprintf("%v/repos/%v/%v/issues/%v/labels/%v", gh.baseURL, repoOwner, repoName, issueNumber, url.PathEscape(label)), "", []int{http.StatusOK}, nil)
+
+	return
+}
+
+func (gh *apiClientImpl) GetIssueComments(ctx context.Context, repoOwner, repoName string, issueNumber int) (comments []*Comment, err error) {
+
+	// https://developer.github.com/v3/issues/comments/#list-comments-on-an-issue
+	pages, err := gh.callGithubAPIPaginated(ctx, fmt.Sprintf("%v/repos/%v/%v/issues/%v/comments?per_page=100", gh.baseURL, repoOwner, repoName, issueNumber))
+	if err != nil {
+		return
+	}
+
+	comments = make([]*Comment, 0)
+	for _, body := range pages {
+		var page []*Comment
+		err = json.Unmarshal(body, &page)
+		if err != nil {
+			return
+		}
+		comments = append(comments, page...)
+	}
+
+	return comments, nil
+}
+
+func (gh *apiClientImpl) CreateIssueComment(ctx context.Context, repoOwner, repoName string, issueNumber int, body string) (comment *Comment, err error) {
+
+	// https://developer.github.com/v3/issues/comments/#create-a-comment
+	responseBody, err := gh.callGithubAPI(ctx, "POST", fmt.Sprintf("%v/repos/%v/%v/issues/%v/comments", gh.baseURL, repoOwner, repoName, issueNumber), "application/json", []int{http.StatusCreated}, CommentRequest{Body: body})
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(responseBody, &comment)
+	if err != nil {
+		return
+	}
+
+	return comment, nil
+}
+
+func (gh *apiClientImpl) DeleteIssueComment(ctx context.Context, repoOwner, repoName string, comment Comment) (err error) {
+
+	// https://developer.github.com/v3/issues/comments/#delete-a-comment
+	_, err = gh.callGithubAPI(ctx, "DELETE", fmt.Sprintf("%v/repos/%v/%v/issues/comments/%v", gh.baseURL, repoOwner, repoName, comment.ID), "", []int{http.StatusNoContent}, nil)
+
+	return
+}
+
+func (gh *apiClientImpl) GetIssuesAndPullRequestsForMilestone(ctx context.Context, repoOwner, repoName string, milestone Milestone) (issues []*Issue, pullRequests []*PullRequest, err error) {
+
+	log.Info().Msgf("Retrieving issues for milestone #%v...", milestone.Number)
+
+	issuesAndPullRequests, err := gh.GetIssuesForMilestone(ctx, repoOwner, repoName, milestone, "closed")
+	if err != nil {
+		return
+	}
+
+	// separate pull requests from returned issues
+	issues = make([]*Issue, 0)
+	pullRequests = make([]*PullRequest, 0)
+	for _, i := range issuesAndPullRequests {
+		if i.PullRequest != nil {
+			// map issue to pull request
+			pullRequest := i.getPullRequest(&milestone)
+			if pullRequest != nil {
+				pullRequests = append(pullRequests, pullRequest)
+			}
+		} else {
+			issues = append(issues, i)
+		}
+	}
+
+	log.Info().Msgf("Retrieved %v issues and %v pull requests", len(issues), len(pullRequests))
+
+	return issues, pullRequests, nil
+}
+
+func (gh *apiClientImpl) GetRepository(ctx context.Context, repoOwner, repoName string) (repository *Repository, err error) {
+
+	// https://developer.github.com/v3/repos/#get
+	body, err := gh.callGithubAPI(ctx, "GET", fmt.Sprintf("%v/repos/%v/%v", gh.baseURL, repoOwner, repoName), "", []int{http.StatusOK}, nil)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(body, &repository)
+	if err != nil {
+		return
+	}
+
+	return repository, nil
+}
+
+func (gh *apiClientImpl) GetCombinedStatus(ctx context.Context, repoOwner, repoName, gitRevision string) (combinedStatus *CombinedStatus, err error) {
+
+	// https://developer.github.com/v3/repos/statuses/#get-the-combined-status-for-a-specific-ref
+	log.Info().Msgf("Retrieving combined status for %v...", gitRevision)
+
+	pages, err := gh.callGithubAPIPaginated(ctx, fmt.Sprintf("%v/repos/%v/%v/commits/%v/status?per_page=100", gh.baseURL, repoOwner, repoName, gitRevision))
+	if err != nil {
+		return
+	}
+
+	// each page repeats the combined state with the next batch of statuses
+	for _, body := range pages {
+		var page *CombinedStatus
+		err = json.Unmarshal(body, &page)
+		if err != nil {
+			return nil, err
+		}
+		if combinedStatus == nil {
+			combinedStatus = page
+			continue
+		}
+		combinedStatus.Statuses = append(combinedStatus.Statuses, page.Statuses...)
+	}
+	if combinedStatus == nil {
+		return nil, fmt.Errorf("Retrieving combined status for %v returned no response", gitRevision)
+	}
+
+	log.Info().Msgf("Retrieved combined status %v with %v statuses", combinedStatus.State, len(combinedStatus.Statuses))
+
+	return combinedStatus, nil
+}
+
+func (gh *apiClientImpl) GetCheckRuns(ctx context.Context, repoOwner, repoName, gitRevision string) (checkRuns []*CheckRun, err error) {
+
+	// https://developer.github.com/v3/checks/runs/#list-check-runs-for-a-specific-ref
+	log.Info().Msgf("Retrieving check runs for %v...", gitRevision)
+
+	pages, err := gh.callGithubAPIPaginated(ctx, fmt.Sprintf("%v/repos/%v/%v/commits/%v/check-runs?per_page=100", gh.baseURL, repoOwner, repoName, gitRevision))
+	if err != nil {
+		return
+	}
+
+	checkRuns = make([]*CheckRun, 0)
+	for _, body := range pages {
+		var page CheckRuns
+		err = json.Unmarshal(body, &page)
+		if err != nil {
+			return
+		}
+		checkRuns = append(checkRuns, page.CheckRuns...)
+	}
+
+	log.Info().Msgf("Retrieved %v check runs", len(checkRuns))
+
+	return checkRuns, nil
+}
+
+func (gh *apiClientImpl) GetCommit(ctx context.Context, repoOwner, repoName, gitRevision string) (commit *Commit, err error) {
+
+	// https://developer.github.com/v3/repos/commits/#get-a-single-commit
+	log.Info().Msgf("Retrieving commit %v...", gitRevision)
+
+	body, err := gh.callGithubAPI(ctx, "GET", fmt.Sprintf("%v/repos/%v/%v/commits/%v", gh.baseURL, repoOwner, repoName, gitRevision), "", []int{http.StatusOK}, nil)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(body, &commit)
+	if err != nil {
+		return
+	}
+
+	log.Info().Msg("Retrieved commit")
+
+	return commit, nil
+}
+
+func (gh *apiClientImpl) GetIssue(ctx context.Context, repoOwner, repoName string, issueNumber int) (issue *Issue, err error) {
+
+	// https://developer.github.com/v3/issues/#get-a-single-issue
+	body, err := gh.callGithubAPI(ctx, "GET", fmt.Sprintf("%v/repos/%v/%v/issues/%v", gh.baseURL, repoOwner, repoName, issueNumber), "", []int{http.StatusOK}, nil)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(body, &issue)
+	if err != nil {
+		return
+	}
+
+	return issue, nil
+}
+
+func (gh *apiClientImpl) GetTags(ctx context.Context, repoOwner, repoName string) (tags []*Tag, err error) {
+
+	// https://developer.github.com/v3/repos/#list-tags
+	log.Info().Msg("Retrieving tags...")
+
+	pages, err := gh.callGithubAPIPaginated(ctx, fmt.Sprintf("%v/repos/%v/%v/tags?per_page=100", gh.baseURL, repoOwner, repoName))
+	if err != nil {
+		return
+	}
+
+	tags = make([]*Tag, 0)
+	for _, body := range pages {
+		var page []*Tag
+		err = json.Unmarshal(body, &page)
+		if err != nil {
+			return
+		}
+		tags = append(tags, page...)
+	}
+
+	log.Info().Msgf("Retrieved %v tags", len(tags))
+
+	return tags, nil
+}
+
+func (gh *apiClientImpl) CompareCommits(ctx context.Context, repoOwner, repoName, base, head string) (comparison *Comparison, err error) {
+
+	// https://developer.github.com/v3/repos/commits/#compare-two-commits; without paging the commits are cut off at 250
+	log.Info().Msgf("Comparing %v...%v...", base, head)
+
+	pages, err := gh.callGithubAPIPaginated(ctx, fmt.Sprintf("%v/repos/%v/%v/compare/%v...%v?per_page=100", gh.baseURL, repoOwner, repoName, base, head))
+	if err != nil {
+		return
+	}
+
+	// each page repeats the comparison with the next batch of commits
+	for _, body := range pages {
+		var page *Comparison
+		err = json.Unmarshal(body, &page)
+		if err != nil {
+			return nil, err
+		}
+		if comparison == nil {
+			comparison = page
+			continue
+		}
+		comparison.Commits = append(comparison.Commits, page.Commits...)
+	}
+	if comparison == nil {
+		return nil, fmt.Errorf("Comparing %v...%v returned no response", base, head)
+	}
+
+	log.Info().Msgf("Compared %v...%v, status %v with %v commits", base, head, comparison.Status, comparison.TotalCommits)
+
+	return comparison, nil
+}
+
+func (gh *apiClientImpl) GetPullRequestsForCommit(ctx context.Context, repoOwner, repoName, sha string) (pullRequests []*PullRequest, err error) {
+
+	// https://developer.github.com/v3/repos/commits/#list-pull-requests-associated-with-commit
+	body, err := gh.callGithubAPI(ctx, "GET", fmt.Sprintf("%v/repos/%v/%v/commits/%v/pulls", gh.baseURL, repoOwner, repoName, sha), "", []int{http.StatusOK}, nil)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(body, &pullRequests)
+	if err != nil {
+		return
+	}
+
+	return pullRequests, nil
+}
+
+func (gh *apiClientImpl) GetTokenScopes(ctx context.Context) (scopes []string, hasScopes bool, err error) {
+
+	// https://developer.github.com/apps/building-oauth-apps/understanding-scopes-for-oauth-apps/
+	log.Info().Msg("Retrieving token scopes...")
+
+	_, header, err := gh.callGithubAPIWithResponseHeaders(ctx, "GET", gh.baseURL+"/", "", []int{http.StatusOK}, nil)
+	if err != nil {
+		return
+	}
+
+	// tokens for github apps and fine-grained tokens don't have oauth scopes
+	if _, hasScopes = header["X-Oauth-Scopes"]; !hasScopes {
+		log.Info().Msg("Token has no oauth scopes")
+		return nil, false, nil
+	}
+
+	scopes = make([]string, 0)
+	for _, s := range strings.Split(header.Get("X-OAuth-Scopes"), ",") {
+		if strings.TrimSpace(s) != "" {
+			scopes = append(scopes, strings.TrimSpace(s))
+		}
+	}
+
+	log.Info().Msgf("Retrieved token scopes %v", scopes)
+
+	return scopes, true, nil
+}
+
+func (gh *apiClientImpl) GetReleaseByTag(ctx context.Context, repoOwner, repoName, tagName string) (release *Release, err error) {
+
+	// https://developer.github.com/v3/repos/releases/#get-a-release-by-tag-name
+	log.Info().Msgf("Retrieving release for tag %v...", tagName)
+
+	body, err := gh.callGithubAPI(ctx, "GET", fmt.Sprintf("%v/repos/%v/%v/releases/tags/%v", gh.baseURL, repoOwner, repoName, tagName), "", []int{http.StatusOK}, nil)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(body, &release)
+	if err != nil {
+		return
+	}
+
+	log.Info().Msgf("Retrieved release %v", release.Name)
+
+	return release, nil
+}
+
+func (gh *apiClientImpl) PublishRelease(ctx context.Context, repoOwner, repoName string, release Release) (createdRelease *Release, err error) {
+
+	// https://developer.github.com/v3/repos/releases/#create-a-release
+	var responseBody []byte
+	responseBody, err = gh.callGithubAPI(ctx, "POST", fmt.Sprintf("%v/repos/%v/%v/releases", gh.baseURL, repoOwner, repoName), "application/json", []int{http.StatusCreated}, release)
+
+	if IsAlreadyExistsError(err) {
+		log.Info().Msg("Release already exist, skipping")
+		return createdRelease, nil
+	} else if err != nil {
+		return
+	}
+
+	log.Info().Msg("Created release")
+
+	err = json.Unmarshal(responseBody, &release)
+	if err != nil {
+		return
+	}
+
+	createdRelease = &release
+
+	return createdRelease, nil
+}
+
+func (gh *apiClientImpl) UploadReleaseAsset(ctx context.Context, createdRelease Release, name, label, contentType string, content []byte) (uploadedAsset *ReleaseAsset, err error) {
+
+	// https://developer.github.com/v3/repos/releases/#upload-a-release-asset
+	log.Info().Msgf("Uploading release asset %v...", name)
+
+	query := url.Values{}
+	query.Set("name", name)
+	if label != "" {
+		query.Set("label", label)
+	}
+	uploadURL := strings.Replace(createdRelease.UploadURL, "{?name,label}", "", 1) + "?" + query.Encode()
+
+	responseBody, err := gh.callGithubAPI(ctx, "POST", uploadURL, contentType, []int{http.StatusCreated}, content)
+	if err != nil {
+		return
+	}
+
+	var asset ReleaseAsset
+	err = json.Unmarshal(responseBody, &asset)
+	if err != nil {
+		return
+	}
+
+	digest := sha256.Sum256(content)
+	asset.SHA256 = hex.EncodeToString(digest[:])
+
+	log.Info().Msgf("Uploaded release asset %v", asset.Name)
+
+	return &asset, nil
+}
+
+func (gh *apiClientImpl) CloseMilestone(ctx context.Context, repoOwner, repoName string, milestone Milestone) (err error) {
+
+	log.Info().Msgf("Closing milestone #%v...", milestone.Number)
+
+	err = gh.updateMilestoneState(ctx, repoOwner, repoName, milestone, "closed")
+	if err != nil {
+		return
+	}
+
+	log.Info().Msg("Closed milestone")
+
+	return nil
+}
+
+func (gh *apiClientImpl) ReopenMilestone(ctx context.Context, repoOwner, repoName string, milestone Milestone) (err error) {
+
+	log.Info().Msgf("Reopening milestone #%v...", milestone.Number)
+
+	err = gh.updateMilestoneState(ctx, repoOwner, repoName, milestone, "open")
+	if err != nil {
+		return
+	}
+
+	log.Info().Msg("Reopened milestone")
+
+	return nil
+}
+
+func (gh *apiClientImpl) updateMilestoneState(ctx context.Context, repoOwner, repoName string, milestone Milestone, state string) (err error) {
+
+	// https://developer.github.com/v3/issues/milestones/#update-a-milestone
+	updateRequest := MilestoneUpdateRequest{
+		Title:       milestone.Title,
+		State:       state,
+		Description: milestone.Description,
+		DueOn:       milestone.DueOn,
+	}
+
+	_, err = gh.callGithubAPI(ctx, "PATCH", fmt.Sprintf("%v/repos/%v/%v/milestones/%v", gh.baseURL, repoOwner, repoName, milestone.Number), "application/json", []int{http.StatusOK}, updateRequest)
+
+	return
+}
+
+func (gh *apiClientImpl) GetTagRef(ctx context.Context, repoOwner, repoName, tagName string) (ref *Ref, err error) {
+
+	// https://developer.github.com/v3/git/refs/#get-a-reference
+	log.Info().Msgf("Retrieving ref for tag %v...", tagName)
+
+	body, err := gh.callGithubAPI(ctx, "GET", fmt.Sprintf("%v/repos/%v/%v/git/ref/tags/%v", gh.baseURL, repoOwner, repoName, tagName), "", []int{http.StatusOK}, nil)
+	if err != nil && IsNotFoundError(err) {
+		log.Info().Msgf("Tag %v does not exist", tagName)
+		return nil, nil
+	}
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(body, &ref)
+	if err != nil {
+		return
+	}
+
+	log.Info().Msgf("Retrieved ref for tag %v pointing to %v %v", tagName, ref.Object.Type, ref.Object.SHA)
+
+	return ref, nil
+}
+
+func (gh *apiClientImpl) UpdateTagRef(ctx context.Context, repoOwner, repoName, tagName, sha string) (err error) {
+
+	// https://developer.github.com/v3/git/refs/#update-a-reference
+	log.Info().Msgf("Pointing tag %v to %v...", tagName, sha)
+
+	_, err = gh.callGithubAPI(ctx, "PATCH", fmt.Sprintf("%v/repos/%v/%v/git/refs/tags/%v", gh.baseURL, repoOwner, repoName, tagName), "application/json", []int{http.StatusOK}, RefUpdateRequest{SHA: sha, Force: true})
+	if err != nil {
+		return
+	}
+
+	log.Info().Msgf("Pointed tag %v to %v", tagName, sha)
+
+	return nil
+}
+
+func (gh *apiClientImpl) GetTagObject(ctx context.Context, repoOwner, repoName, sha string) (tag *TagObject, err error) {
+
+	// https://developer.github.com/v3/git/tags/#get-a-tag
+	body, err := gh.callGithubAPI(ctx, "GET", fmt.Sprintf("%v/repos/%v/%v/git/tags/%v", gh.baseURL, repoOwner, repoName, sha), "", []int{http.StatusOK}, nil)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(body, &tag)
+	if err != nil {
+		return
+	}
+
+	return tag, nil
+}
+
+func (gh *apiClientImpl) CreateTagObject(ctx context.Context, repoOwner, repoName string, createRequest TagCreateRequest) (tag *TagObject, err error) {
+
+	// https://developer.github.com/v3/git/tags/#create-a-tag-object
+	log.Info().Msgf("Creating annotated tag object %v for %v...", createRequest.Tag, createRequest.Object)
+
+	body, err := gh.callGithubAPI(ctx, "POST", fmt.Sprintf("%v/repos/%v/%v/git/tags", gh.baseURL, repoOwner, repoName), "application/json", []int{http.StatusCreated}, createRequest)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(body, &tag)
+	if err != nil {
+		return
+	}
+
+	log.Info().Msgf("Created annotated tag object %v", tag.SHA)
+
+	return tag, nil
+}
+
+func (gh *apiClientImpl) CreateTagRef(ctx context.Context, repoOwner, repoName, tagName, sha string) (err error) {
+
+	// https://developer.github.com/v3/git/refs/#create-a-reference
+	log.Info().Msgf("Creating tag %v pointing to %v...", tagName, sha)
+
+	_, err = gh.callGithubAPI(ctx, "POST", fmt.Sprintf("%v/repos/%v/%v/git/refs", gh.baseURL, repoOwner, repoName), "application/json", []int{http.StatusCreated}, RefCreateRequest{Ref: "refs/tags/" + tagName, SHA: sha})
+	if err != nil {
+		return
+	}
+
+	log.Info().Msgf("Created tag %v", tagName)
+
+	return nil
+}
+
+func (gh *apiClientImpl) DeleteTagRef(ctx context.Context, repoOwner, repoName, tagName string) (err error) {
+
+	// https://developer.github.com/v3/git/refs/#delete-a-reference
+	log.Info().Msgf("Deleting tag %v...", tagName)
+
+	_, err = gh.callGithubAPI(ctx, "DELETE", fmt.Sprintf("%v/repos/%v/%v/git/refs/tags/%v", gh.baseURL, repoOwner, repoName, tagName), "", []int{http.StatusNoContent}, nil)
+	if err != nil {
+		return
+	}
+
+	log.Info().Msgf("Deleted tag %v", tagName)
+
+	return nil
+}
+
+func (gh *apiClientImpl) DeleteRelease(ctx context.Context, repoOwner, repoName string, release Release) (err error) {
+
+	// https://developer.github.com/v3/repos/releases/#delete-a-release
+	log.Info().Msgf("Deleting release %v...", release.Name)
+
+	_, err = gh.callGithubAPI(ctx, "DELETE", fmt.Sprintf("%v/repos/%v/%v/releases/%v", gh.baseURL, repoOwner, repoName, release.ID), "", []int{http.StatusNoContent}, nil)
+	if err != nil {
+		return
+	}
+
+	log.Info().Msgf("Deleted release %v", release.Name)
+
+	return nil
+}
+
+// callGithubAPIPaginated retrieves all pages of a list by following the next links in the Link header
+func (gh *apiClientImpl) callGithubAPIPaginated(ctx context.Context, url string) (pages [][]byte, err error) {
+
+	// https://developer.github.com/v3/#pagination
+	for url != "" {
+		body, header, err := gh.callGithubAPIWithResponseHeaders(ctx, "GET", url, "", []int{http.StatusOK}, nil)
+		if err != nil {
+			return pages, err
+		}
+		pages = append(pages, body)
+		url = GetNextPageURL(header.Get("Link"))
+	}
+
+	return pages, nil
+}
+
+func (gh *apiClientImpl) callGithubAPI(ctx context.Context, method, url, contentType string, validStatusCodes []int, params interface{}) (body []byte, err error) {
+	body, _, err = gh.callGithubAPIWithResponseHeaders(ctx, method, url, contentType, validStatusCodes, params)
+	return
+}
+
+func (gh *apiClientImpl) callGithubAPIWithResponseHeaders(ctx context.Context, method, url, contentType string, validStatusCodes []int, params interface{}) (body []byte, header http.Header, err error) {
+
+	// convert params to json if they're present
+	var requestBody io.Reader
+	if params != nil {
+		switch contentType {
+		case "application/json":
+			data, err := json.Marshal(params)
+			if err != nil {
+				return body, header, err
+			}
+			requestBody = bytes.NewReader(data)
+		case "application/zip":
+			requestBody = bytes.NewReader(params.([]byte))
+		default:
+			requestBody = bytes.NewReader(params.([]byte))
+		}
+	}
+
+	// limit the time for this request including retries, within the overall deadline of the context; without a request timeout only that deadline applies
+	if gh.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, gh.requestTimeout)
+		defer cancel()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, url, requestBody)
+	if err != nil {
+		return
+	}
+	if contentType != "" {
+		request.Header.Add("Content-Type", contentType)
+	}
+
+	// perform actual request
+	response, err := gh.client.Do(request)
+	if err != nil {
+		return
+	}
+
+	defer response.Body.Close()
+
+	header = response.Header
+
+	body, err = ioutil.ReadAll(response.Body)
+	if err != nil {
+		return
+	}
+
+	hasValidStatusCode := false
+	for _, sc := range validStatusCodes {
+		if response.StatusCode == sc {
+			hasValidStatusCode = true
+		}
+	}
+	if !hasValidStatusCode {
+		return body, header, NewAPIError(method, url, response.StatusCode, header, body)
+	}
+
+	if string(body) == "" {
+		log.Info().Msgf("Received successful response without body for '%v %v' with status code %v", method, url, response.StatusCode)
+		return
+	}
+
+	// unmarshal json body
+	var b interface{}
+	err = json.Unmarshal(body, &b)
+	if err != nil {
+		log.Info().Msgf("Deserializing response for '%v' Github api call failed. Body: %v. Error: %v", url, string(body), err)
+		return
+	}
+
+	return
+}
+
+// GetNextPageURL returns the url with rel="next" from a Link header, see https://developer.github.com/v3/#pagination
+func GetNextPageURL(linkHeader string) string {
+	for _, link := range strings.Split(linkHeader, ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 {
+			continue
+		}
+		for _, p := range parts[1:] {
+			if strings.TrimSpace(p) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			}
+		}
+	}
+
+	return ""
+}
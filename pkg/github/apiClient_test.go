@@ -0,0 +1,152 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGithubAPIClientTimeouts(t *testing.T) {
+
+	t.Run("ReturnsErrorWhenRequestTimeoutPasses", func(t *testing.T) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}))
+		defer server.Close()
+
+		client := NewAPIClient(Options{BaseURL: server.URL, AccessToken: "secret", RequestTimeout: 50 * time.Millisecond})
+		start := time.Now()
+
+		// act
+		_, err := client.GetRepository(context.Background(), "estafette", "app")
+
+		assert.NotNil(t, err)
+		assert.True(t, time.Since(start) < 2*time.Second)
+	})
+
+	t.Run("SucceedsWithoutRequestTimeout", func(t *testing.T) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"name":"app"}`))
+		}))
+		defer server.Close()
+
+		client := NewAPIClient(Options{BaseURL: server.URL, AccessToken: "secret"})
+
+		// act
+		repository, err := client.GetRepository(context.Background(), "estafette", "app")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "app", repository.Name)
+	})
+
+	t.Run("ReturnsErrorWhenContextIsCancelled", func(t *testing.T) {
+
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+		}))
+		defer server.Close()
+
+		client := NewAPIClient(Options{BaseURL: server.URL, AccessToken: "secret", RequestTimeout: time.Minute})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// act
+		_, err := client.GetRepository(ctx, "estafette", "app")
+
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, requests)
+	})
+}
+
+func TestCompareCommits(t *testing.T) {
+
+	t.Run("CollectsCommitsOfAllPages", func(t *testing.T) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/repos/estafette/app/compare/v1.1.0...abc", r.URL.Path)
+			if r.URL.Query().Get("page") == "" {
+				w.Header().Set("Link", fmt.Sprintf(`<http://%v%v?per_page=100&page=2>; rel="next"`, r.Host, r.URL.Path))
+				fmt.Fprint(w, `{"status":"ahead","total_commits":2,"commits":[{"sha":"first"}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"status":"ahead","total_commits":2,"commits":[{"sha":"second"}]}`)
+		}))
+		defer server.Close()
+
+		client := NewAPIClient(Options{BaseURL: server.URL, AccessToken: "secret", RequestTimeout: time.Minute})
+
+		// act
+		comparison, err := client.CompareCommits(context.Background(), "estafette", "app", "v1.1.0", "abc")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "ahead", comparison.Status)
+		if assert.Equal(t, 2, len(comparison.Commits)) {
+			assert.Equal(t, "second", comparison.Commits[1].SHA)
+		}
+	})
+}
+
+func TestGetCombinedStatus(t *testing.T) {
+
+	t.Run("CollectsStatusesOfAllPages", func(t *testing.T) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("page") == "" {
+				w.Header().Set("Link", fmt.Sprintf(`<http://%v%v?per_page=100&page=2>; rel="next"`, r.Host, r.URL.Path))
+				fmt.Fprint(w, `{"state":"failure","total_count":2,"statuses":[{"context":"ci/build","state":"success"}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"state":"failure","total_count":2,"statuses":[{"context":"ci/e2e","state":"failure"}]}`)
+		}))
+		defer server.Close()
+
+		client := NewAPIClient(Options{BaseURL: server.URL, AccessToken: "secret", RequestTimeout: time.Minute})
+
+		// act
+		combinedStatus, err := client.GetCombinedStatus(context.Background(), "estafette", "app", "abc")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "failure", combinedStatus.State)
+		if assert.Equal(t, 2, len(combinedStatus.Statuses)) {
+			assert.Equal(t, "ci/e2e", combinedStatus.Statuses[1].Context)
+		}
+	})
+}
+
+func TestGetNextPageURL(t *testing.T) {
+
+	t.Run("ReturnsEmptyStringForEmptyHeader", func(t *testing.T) {
+
+		// act
+		url := GetNextPageURL("")
+
+		assert.Equal(t, "", url)
+	})
+
+	t.Run("ReturnsURLWithRelNext", func(t *testing.T) {
+
+		// act
+		url := GetNextPageURL(`<https://api.github.com/repositories/1/milestones?page=2>; rel="next", <https://api.github.com/repositories/1/milestones?page=5>; rel="last"`)
+
+		assert.Equal(t, "https://api.github.com/repositories/1/milestones?page=2", url)
+	})
+
+	t.Run("ReturnsEmptyStringOnLastPage", func(t *testing.T) {
+
+		// act
+		url := GetNextPageURL(`<https://api.github.com/repositories/1/milestones?page=1>; rel="first", <https://api.github.com/repositories/1/milestones?page=4>; rel="prev"`)
+
+		assert.Equal(t, "", url)
+	})
+}
@@ -0,0 +1,127 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// maxAPIErrorMessageLength limits how much of a response body without json message ends up in the error
+	maxAPIErrorMessageLength = 500
+)
+
+// APIError is returned for api responses with an unexpected status code, so callers can branch on its fields with errors.As
+type APIError struct {
+	StatusCode       int
+	Method           string
+	URL              string
+	Message          string
+	Codes            []string
+	DocumentationURL string
+	RequestID        string
+}
+
+// errorResponse is the body Github returns for failed requests, see https://developer.github.com/v3/#client-errors
+type errorResponse struct {
+	Message          string `json:"message"`
+	DocumentationURL string `json:"documentation_url"`
+	Errors           []struct {
+		Resource string `json:"resource"`
+		Field    string `json:"field"`
+		Code     string `json:"code"`
+	} `json:"errors"`
+}
+
+// NewAPIError creates an error from a failed response, using the message and error codes in its json body if present
+func NewAPIError(method, url string, statusCode int, header http.Header, body []byte) *APIError {
+
+	apiError := &APIError{
+		StatusCode: statusCode,
+		Method:     method,
+		URL:        url,
+		Codes:      make([]string, 0),
+	}
+	if header != nil {
+		apiError.RequestID = header.Get("X-GitHub-Request-Id")
+	}
+
+	var response errorResponse
+	if err := json.Unmarshal(body, &response); err == nil && response.Message != "" {
+		apiError.Message = response.Message
+		apiError.DocumentationURL = response.DocumentationURL
+		for _, e := range response.Errors {
+			if e.Code != "" {
+				apiError.Codes = append(apiError.Codes, e.Code)
+			}
+		}
+		return apiError
+	}
+
+	// fall back to the raw body for responses that aren't json, like those of proxies
+	apiError.Message = strings.TrimSpace(string(body))
+	if len(apiError.Message) > maxAPIErrorMessageLength {
+		apiError.Message = apiError.Message[:maxAPIErrorMessageLength] + "..."
+	}
+
+	return apiError
+}
+
+func (e *APIError) Error() string {
+
+	message := fmt.Sprintf("%v %v returned status code %v", e.Method, e.URL, e.StatusCode)
+	if e.Message != "" {
+		message += fmt.Sprintf(": %v", e.Message)
+	}
+	if len(e.Codes) > 0 {
+		message += fmt.Sprintf(" (%v)", strings.Join(e.Codes, ", "))
+	}
+	if e.RequestID != "" {
+		message += fmt.Sprintf(", request id %v", e.RequestID)
+	}
+	if e.DocumentationURL != "" {
+		message += fmt.Sprintf(", see %v", e.DocumentationURL)
+	}
+
+	return message
+}
+
+// HasCode returns true if one of the errors in the response has the code, like already_exists
+func (e *APIError) HasCode(code string) bool {
+	for _, c := range e.Codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotFoundError returns true if the error or one it wraps is an api error with status 404
+func IsNotFoundError(err error) bool {
+	var apiError *APIError
+	return errors.As(err, &apiError) && apiError.StatusCode == http.StatusNotFound
+}
+
+// IsAlreadyExistsError returns true if the error or one it wraps is a 422 api error with code already_exists, like for a tag or milestone that exists
+func IsAlreadyExistsError(err error) bool {
+	var apiError *APIError
+	return errors.As(err, &apiError) && apiError.StatusCode == http.StatusUnprocessableEntity && apiError.HasCode("already_exists")
+}
+
+// WithAPIErrorFields adds the fields of an api error as separate log fields, so they can be filtered on
+func WithAPIErrorFields(event *zerolog.Event, err error) *zerolog.Event {
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		event = event.
+			Int("statusCode", apiError.StatusCode).
+			Str("method", apiError.Method).
+			Str("url", apiError.URL).
+			Strs("codes", apiError.Codes).
+			Str("requestId", apiError.RequestID)
+	}
+	return event.Err(err)
+}
@@ -0,0 +1,86 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAPIError(t *testing.T) {
+
+	t.Run("ParsesGithubErrorResponse", func(t *testing.T) {
+
+		header := http.Header{}
+		header.Set("X-GitHub-Request-Id", "C0DE:1234")
+		body := []byte(`{"message":"Validation Failed","errors":[{"resource":"Release","code":"already_exists","field":"tag_name"}],"documentation_url":"https://developer.github.com/v3/repos/releases/#create-a-release"}`)
+
+		// act
+		apiError := NewAPIError("POST", "https://api.github.com/repos/estafette/app/releases", http.StatusUnprocessableEntity, header, body)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, apiError.StatusCode)
+		assert.Equal(t, "Validation Failed", apiError.Message)
+		assert.Equal(t, []string{"already_exists"}, apiError.Codes)
+		assert.Equal(t, "https://developer.github.com/v3/repos/releases/#create-a-release", apiError.DocumentationURL)
+		assert.Equal(t, "C0DE:1234", apiError.RequestID)
+		assert.Equal(t, "POST https://api.github.com/repos/estafette/app/releases returned status code 422: Validation Failed (already_exists), request id C0DE:1234, see https://developer.github.com/v3/repos/releases/#create-a-release", apiError.Error())
+	})
+
+	t.Run("TruncatesBodyThatIsNotJson", func(t *testing.T) {
+
+		body := []byte("<html>" + strings.Repeat("a", 1000) + "</html>")
+
+		// act
+		apiError := NewAPIError("GET", "https://api.github.com/", http.StatusBadGateway, nil, body)
+
+		assert.Equal(t, maxAPIErrorMessageLength+3, len(apiError.Message))
+		assert.Equal(t, 0, len(apiError.Codes))
+	})
+}
+
+func TestIsNotFoundError(t *testing.T) {
+
+	t.Run("ReturnsTrueForWrappedNotFoundAPIError", func(t *testing.T) {
+
+		err := fmt.Errorf("Retrieving tag failed: %w", NewAPIError("GET", "https://api.github.com/repos/estafette/app/git/ref/tags/v1.2.0", http.StatusNotFound, nil, []byte(`{"message":"Not Found"}`)))
+
+		// act
+		notFound := IsNotFoundError(err)
+
+		assert.True(t, notFound)
+	})
+
+	t.Run("ReturnsFalseForOtherErrors", func(t *testing.T) {
+
+		// act
+		notFound := IsNotFoundError(errors.New("Status code 404"))
+
+		assert.False(t, notFound)
+	})
+}
+
+func TestIsAlreadyExistsError(t *testing.T) {
+
+	t.Run("ReturnsTrueForValidationErrorWithAlreadyExistsCode", func(t *testing.T) {
+
+		err := NewAPIError("POST", "https://api.github.com/repos/estafette/app/releases", http.StatusUnprocessableEntity, nil, []byte(`{"message":"Validation Failed","errors":[{"code":"already_exists"}]}`))
+
+		// act
+		alreadyExists := IsAlreadyExistsError(err)
+
+		assert.True(t, alreadyExists)
+	})
+
+	t.Run("ReturnsFalseForOtherValidationErrors", func(t *testing.T) {
+
+		err := NewAPIError("POST", "https://api.github.com/repos/estafette/app/releases", http.StatusUnprocessableEntity, nil, []byte(`{"message":"Validation Failed","errors":[{"code":"invalid"}]}`))
+
+		// act
+		alreadyExists := IsAlreadyExistsError(err)
+
+		assert.False(t, alreadyExists)
+	})
+}
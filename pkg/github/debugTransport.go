@@ -0,0 +1,374 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxDebugBodyLength limits how much of a request or response body is logged and saved in the har file
+	maxDebugBodyLength = 2000
+)
+
+var (
+	// secretHeaders have their values replaced entirely in logs and har files
+	secretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "Private-Token"}
+)
+
+// debugMiddleware logs each request and response with headers and truncated bodies and optionally records them in a har file; it should be placed after the auth and headers middleware to trace the requests as they're sent
+func debugMiddleware(recorder *HARRecorder) middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+
+			requestBody := readRequestBodyForDebug(request)
+			start := time.Now()
+
+			response, err := next.RoundTrip(request)
+			duration := time.Since(start)
+
+			if err != nil {
+				log.Debug().
+					Str("method", request.Method).
+					Str("url", redactURL(request.URL)).
+					Interface("requestHeaders", redactHeaders(request.Header)).
+					Str("requestBody", requestBody).
+					Dur("duration", duration).
+					Str("error", redactSecrets(err.Error())).
+					Msgf("'%v %v' failed after %v", request.Method, redactURL(request.URL), duration)
+				recorder.record(request, requestBody, nil, "", err, start, duration)
+				return response, err
+			}
+
+			responseBody := readResponseBodyForDebug(response)
+
+			log.Debug().
+				Str("method", request.Method).
+				Str("url", redactURL(request.URL)).
+				Interface("requestHeaders", redactHeaders(request.Header)).
+				Str("requestBody", requestBody).
+				Int("statusCode", response.StatusCode).
+				Dur("duration", duration).
+				Str("rateLimitRemaining", response.Header.Get("X-RateLimit-Remaining")).
+				Str("rateLimitReset", response.Header.Get("X-RateLimit-Reset")).
+				Str("requestId", response.Header.Get("X-GitHub-Request-Id")).
+				Interface("responseHeaders", redactHeaders(response.Header)).
+				Str("responseBody", responseBody).
+				Msgf("'%v %v' returned status code %v in %v", request.Method, redactURL(request.URL), response.StatusCode, duration)
+
+			recorder.record(request, requestBody, response, responseBody, nil, start, duration)
+
+			return response, err
+		})
+	}
+}
+
+// readRequestBodyForDebug returns the start of the request body without consuming it, using the copy http.NewRequest keeps for redirects
+func readRequestBodyForDebug(request *http.Request) string {
+	if request.Body == nil || request.Body == http.NoBody {
+		return ""
+	}
+	if !isTextContentType(request.Header.Get("Content-Type")) {
+		return fmt.Sprintf("(%v bytes of %v)", request.ContentLength, request.Header.Get("Content-Type"))
+	}
+	if request.GetBody == nil {
+		return "(body can't be read without consuming it)"
+	}
+
+	body, err := request.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+
+	prefix, _ := ioutil.ReadAll(io.LimitReader(body, maxDebugBodyLength+1))
+
+	return formatDebugBody(prefix)
+}
+
+// readResponseBodyForDebug returns the start of the response body and puts it back in front of the rest, so the caller can still read the entire body
+func readResponseBodyForDebug(response *http.Response) string {
+	if response.Body == nil {
+		return ""
+	}
+	if !isTextContentType(response.Header.Get("Content-Type")) {
+		return fmt.Sprintf("(%v bytes of %v)", response.ContentLength, response.Header.Get("Content-Type"))
+	}
+
+	prefix, _ := ioutil.ReadAll(io.LimitReader(response.Body, maxDebugBodyLength+1))
+	response.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), response.Body), response.Body}
+
+	return formatDebugBody(prefix)
+}
+
+func formatDebugBody(prefix []byte) string {
+	if len(prefix) > maxDebugBodyLength {
+		return redactSecrets(string(prefix[:maxDebugBodyLength])) + "... (truncated)"
+	}
+	return redactSecrets(string(prefix))
+}
+
+func isTextContentType(contentType string) bool {
+	return contentType == "" ||
+		strings.HasPrefix(contentType, "text/") ||
+		strings.Contains(contentType, "json") ||
+		strings.Contains(contentType, "xml") ||
+		strings.HasPrefix(contentType, "application/x-www-form-urlencoded")
+}
+
+// redactHeaders copies the headers with the values of secret headers and headers named like a token replaced and token-like values redacted
+func redactHeaders(header http.Header) http.Header {
+	redacted := http.Header{}
+	for name, values := range header {
+		for _, v := range values {
+			redacted.Add(name, redactSecrets(v))
+		}
+		if strings.Contains(strings.ToLower(name), "token") {
+			redacted.Set(name, "REDACTED")
+		}
+	}
+	for _, name := range secretHeaders {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, "REDACTED")
+		}
+	}
+	return redacted
+}
+
+// HARRecorder saves traced requests in a har file that can be opened in browser developer tools, see http://www.softwareishard.com/blog/har-12-spec/
+type HARRecorder struct {
+	path    string
+	creator harCreator
+	mutex   sync.Mutex
+	file    *os.File
+}
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string      `json:"version"`
+	Creator harCreator  `json:"creator"`
+	Entries []*harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	Cookies     []harNameValue `json:"cookies"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Cookies     []harNameValue `json:"cookies"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+	// Error is the custom field browsers use for requests that failed without a response
+	Error string `json:"_error,omitempty"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harTrailer closes the entries and the log; it's written after each entry and overwritten by the next one, so the file is valid json even when the release fails halfway
+const harTrailer = "\n]}}\n"
+
+// NewHARRecorder creates a recorder writing to the har file at path; pass it as HAR in the client options with Debug enabled
+func NewHARRecorder(path, creatorName, creatorVersion string) *HARRecorder {
+	return &HARRecorder{
+		path:    path,
+		creator: harCreator{Name: creatorName, Version: creatorVersion},
+	}
+}
+
+// record appends an entry for the response or, if the request failed without one, for the error
+func (r *HARRecorder) record(request *http.Request, requestBody string, response *http.Response, responseBody string, err error, start time.Time, duration time.Duration) {
+	if r == nil {
+		return
+	}
+
+	milliseconds := float64(duration) / float64(time.Millisecond)
+	entry := &harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Time:            milliseconds,
+		Request: harRequest{
+			Method:      request.Method,
+			URL:         redactURL(request.URL),
+			HTTPVersion: request.Proto,
+			Headers:     toHARNameValues(redactHeaders(request.Header)),
+			QueryString: make([]harNameValue, 0),
+			Cookies:     make([]harNameValue, 0),
+			HeadersSize: -1,
+			BodySize:    request.ContentLength,
+		},
+		Timings: harTimings{
+			Wait: milliseconds,
+		},
+	}
+	if response != nil {
+		entry.Response = harResponse{
+			Status:      response.StatusCode,
+			StatusText:  http.StatusText(response.StatusCode),
+			HTTPVersion: response.Proto,
+			Headers:     toHARNameValues(redactHeaders(response.Header)),
+			Cookies:     make([]harNameValue, 0),
+			Content: harContent{
+				Size:     response.ContentLength,
+				MimeType: response.Header.Get("Content-Type"),
+				Text:     responseBody,
+			},
+			RedirectURL: response.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    response.ContentLength,
+		}
+	} else {
+		entry.Response = harResponse{
+			Headers:     make([]harNameValue, 0),
+			Cookies:     make([]harNameValue, 0),
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+		if err != nil {
+			entry.Response.Error = redactSecrets(err.Error())
+		}
+	}
+	if redactedURL, err := url.Parse(entry.Request.URL); err == nil {
+		for name, values := range redactedURL.Query() {
+			for _, v := range values {
+				entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: name, Value: v})
+			}
+		}
+	}
+	if requestBody != "" {
+		entry.Request.PostData = &harPostData{MimeType: request.Header.Get("Content-Type"), Text: requestBody}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if writeErr := r.append(entry); writeErr != nil {
+		log.Warn().Err(writeErr).Msgf("Writing har file %v failed", r.path)
+	}
+}
+
+// append writes the entry over the trailer of the previous one, instead of rewriting the whole file
+func (r *HARRecorder) append(entry *harEntry) (err error) {
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return
+	}
+
+	if r.file == nil {
+		r.file, err = os.Create(r.path)
+		if err != nil {
+			return
+		}
+		header, err := json.Marshal(harLog{Version: "1.2", Creator: r.creator})
+		if err != nil {
+			return err
+		}
+		// open the log without its empty entries, which are written one by one
+		header = bytes.TrimSuffix(header, []byte(`"entries":null}`))
+		_, err = r.file.Write(append(append([]byte(`{"log":`), header...), []byte(`"entries":[`+"\n")...))
+		if err != nil {
+			return err
+		}
+	} else {
+		_, err = r.file.Seek(-int64(len(harTrailer)), io.SeekEnd)
+		if err != nil {
+			return
+		}
+		data = append([]byte(",\n"), data...)
+	}
+
+	_, err = r.file.Write(append(data, []byte(harTrailer)...))
+
+	return err
+}
+
+// Close closes the har file; the file is complete after each request, so it's safe to skip when the process exits
+func (r *HARRecorder) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+
+	return err
+}
+
+func toHARNameValues(header http.Header) []harNameValue {
+	values := make([]harNameValue, 0, len(header))
+	for name, vs := range header {
+		for _, v := range vs {
+			values = append(values, harNameValue{Name: name, Value: v})
+		}
+	}
+	return values
+}
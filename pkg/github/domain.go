@@ -0,0 +1,371 @@
+package github
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Milestone struct {
+	ID           int    `json:"id"`
+	Number       int    `json:"number"`
+	Title        string `json:"title"`
+	URL          string `json:"url"`
+	HTMLURL      string `json:"html_url"`
+	State        string `json:"state"`
+	OpenIssues   int    `json:"open_issues"`
+	ClosedIssues int    `json:"closed_issues"`
+	Description  string `json:"description"`
+	DueOn        string `json:"due_on"`
+}
+
+type MilestoneUpdateRequest struct {
+	Title       string `json:"title"`
+	State       string `json:"state"`
+	Description string `json:"description,omitempty"`
+	DueOn       string `json:"due_on,omitempty"`
+}
+
+type IssueMilestoneUpdateRequest struct {
+	Milestone *int `json:"milestone"`
+}
+
+type Release struct {
+	ID              int    `json:"id,omitempty"`
+	TagName         string `json:"tag_name"`
+	TargetCommitish string `json:"target_commitish"`
+	Name            string `json:"name"`
+	Body            string `json:"body"`
+	Draft           bool   `json:"draft"`
+	PreRelease      bool   `json:"prerelease"`
+	UploadURL       string `json:"upload_url,omitempty"`
+	HTMLURL         string `json:"html_url,omitempty"`
+}
+
+type ReleaseAsset struct {
+	ID                 int    `json:"id"`
+	Name               string `json:"name"`
+	Label              string `json:"label"`
+	ContentType        string `json:"content_type"`
+	Size               int    `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+
+	// SHA256 is the hex encoded digest of the uploaded content, computed before upload
+	SHA256 string `json:"-"`
+}
+
+type Issue struct {
+	ID          int               `json:"id"`
+	Number      int               `json:"number"`
+	Title       string            `json:"title"`
+	URL         string            `json:"url"`
+	HTMLURL     string            `json:"html_url"`
+	State       string            `json:"state"`
+	Body        string            `json:"body"`
+	User        *User             `json:"user"`
+	Assignee    *User             `json:"assignee"`
+	Milestone   *Milestone        `json:"milestone"`
+	Labels      []*Label          `json:"labels"`
+	PullRequest *IssuePullRequest `json:"pull_request"`
+}
+
+type IssuePullRequest struct {
+	URL     string `json:"url"`
+	HTMLURL string `json:"html_url"`
+}
+
+func (issue *Issue) getPullRequest(milestone *Milestone) *PullRequest {
+	if issue.PullRequest == nil {
+		return nil
+	}
+
+	return &PullRequest{
+		ID:        issue.ID, // Be aware that the id of a pull request returned from "Issues" endpoints will be an issue id. To find out the pull request id, use the "List pull requests" endpoint.
+		Number:    issue.Number,
+		Title:     issue.Title,
+		URL:       issue.PullRequest.URL,
+		HTMLURL:   issue.PullRequest.HTMLURL,
+		State:     issue.State,
+		Body:      issue.Body,
+		User:      issue.User,
+		Assignee:  issue.Assignee,
+		Labels:    issue.Labels,
+		Milestone: milestone,
+	}
+}
+
+type User struct {
+	Login   string `json:"login"`
+	ID      int    `json:"id"`
+	HTMLURL string `json:"html_url"`
+}
+
+type PullRequest struct {
+	ID        int        `json:"id"`
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	URL       string     `json:"url"`
+	HTMLURL   string     `json:"html_url"`
+	State     string     `json:"state"`
+	Body      string     `json:"body"`
+	User      *User      `json:"user"`
+	Assignee  *User      `json:"assignee"`
+	Milestone *Milestone `json:"milestone"`
+	Labels    []*Label   `json:"labels"`
+	Merged    bool       `json:"merged"`
+	MergedAt  *time.Time `json:"merged_at"`
+
+	// LinkedIssueNumbers are the issues the pull request closes, only retrieved by the graphql client
+	LinkedIssueNumbers []int `json:"-"`
+}
+
+type Commit struct {
+	SHA     string        `json:"sha"`
+	HTMLURL string        `json:"html_url"`
+	Commit  CommitDetails `json:"commit"`
+}
+
+type CommitDetails struct {
+	Message   string       `json:"message"`
+	Author    CommitAuthor `json:"author"`
+	Committer CommitAuthor `json:"committer"`
+}
+
+type CommitAuthor struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+type Ref struct {
+	Ref    string    `json:"ref"`
+	URL    string    `json:"url"`
+	Object RefObject `json:"object"`
+}
+
+type RefObject struct {
+	SHA  string `json:"sha"`
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type Tag struct {
+	Name   string    `json:"name"`
+	Commit TagCommit `json:"commit"`
+}
+
+type TagCommit struct {
+	SHA string `json:"sha"`
+	URL string `json:"url"`
+}
+
+type Comparison struct {
+	Status       string    `json:"status"`
+	AheadBy      int       `json:"ahead_by"`
+	BehindBy     int       `json:"behind_by"`
+	TotalCommits int       `json:"total_commits"`
+	Commits      []*Commit `json:"commits"`
+}
+
+type Label struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type LabelsRequest struct {
+	Labels []string `json:"labels"`
+}
+
+type Comment struct {
+	ID      int    `json:"id"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+	User    *User  `json:"user"`
+}
+
+type CommentRequest struct {
+	Body string `json:"body"`
+}
+
+type Repository struct {
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	HTMLURL       string `json:"html_url"`
+	DefaultBranch string `json:"default_branch"`
+	Private       bool   `json:"private"`
+}
+
+type CombinedStatus struct {
+	State      string    `json:"state"`
+	SHA        string    `json:"sha"`
+	TotalCount int       `json:"total_count"`
+	Statuses   []*Status `json:"statuses"`
+}
+
+type Status struct {
+	Context   string `json:"context"`
+	State     string `json:"state"`
+	TargetURL string `json:"target_url"`
+}
+
+type CheckRuns struct {
+	TotalCount int         `json:"total_count"`
+	CheckRuns  []*CheckRun `json:"check_runs"`
+}
+
+type CheckRun struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HTMLURL    string `json:"html_url"`
+}
+
+type TagObject struct {
+	SHA     string    `json:"sha,omitempty"`
+	Tag     string    `json:"tag"`
+	Message string    `json:"message"`
+	Object  RefObject `json:"object"`
+}
+
+type TagCreateRequest struct {
+	Tag     string `json:"tag"`
+	Message string `json:"message"`
+	Object  string `json:"object"`
+	Type    string `json:"type"`
+	Tagger  Tagger `json:"tagger"`
+}
+
+type Tagger struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Date  string `json:"date,omitempty"`
+}
+
+type RefCreateRequest struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+}
+
+type RefUpdateRequest struct {
+	SHA   string `json:"sha"`
+	Force bool   `json:"force"`
+}
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []*graphQLError `json:"errors"`
+}
+
+type graphQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type graphQLRateLimitData struct {
+	RateLimit *graphQLRateLimit `json:"rateLimit"`
+}
+
+type graphQLRateLimit struct {
+	Cost      int `json:"cost"`
+	Remaining int `json:"remaining"`
+}
+
+type graphQLPageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+type graphQLTotalCount struct {
+	TotalCount int `json:"totalCount"`
+}
+
+type graphQLMilestonesData struct {
+	Repository *struct {
+		Milestones struct {
+			Nodes    []*graphQLMilestone `json:"nodes"`
+			PageInfo graphQLPageInfo     `json:"pageInfo"`
+		} `json:"milestones"`
+	} `json:"repository"`
+}
+
+type graphQLMilestone struct {
+	DatabaseID         int               `json:"databaseId"`
+	Number             int               `json:"number"`
+	Title              string            `json:"title"`
+	URL                string            `json:"url"`
+	State              string            `json:"state"`
+	Description        string            `json:"description"`
+	DueOn              *time.Time        `json:"dueOn"`
+	OpenIssues         graphQLTotalCount `json:"openIssues"`
+	ClosedIssues       graphQLTotalCount `json:"closedIssues"`
+	OpenPullRequests   graphQLTotalCount `json:"openPullRequests"`
+	ClosedPullRequests graphQLTotalCount `json:"closedPullRequests"`
+}
+
+type graphQLMilestoneContentsData struct {
+	Repository *struct {
+		Milestone *struct {
+			Issues *struct {
+				Nodes    []*graphQLIssue `json:"nodes"`
+				PageInfo graphQLPageInfo `json:"pageInfo"`
+			} `json:"issues"`
+			PullRequests *struct {
+				Nodes    []*graphQLPullRequest `json:"nodes"`
+				PageInfo graphQLPageInfo       `json:"pageInfo"`
+			} `json:"pullRequests"`
+		} `json:"milestone"`
+	} `json:"repository"`
+}
+
+type graphQLIssue struct {
+	DatabaseID int                    `json:"databaseId"`
+	Number     int                    `json:"number"`
+	Title      string                 `json:"title"`
+	URL        string                 `json:"url"`
+	State      string                 `json:"state"`
+	Body       string                 `json:"body"`
+	Author     *graphQLActor          `json:"author"`
+	Assignees  graphQLUserConnection  `json:"assignees"`
+	Labels     graphQLLabelConnection `json:"labels"`
+}
+
+type graphQLPullRequest struct {
+	DatabaseID              int                    `json:"databaseId"`
+	Number                  int                    `json:"number"`
+	Title                   string                 `json:"title"`
+	URL                     string                 `json:"url"`
+	State                   string                 `json:"state"`
+	Body                    string                 `json:"body"`
+	Merged                  bool                   `json:"merged"`
+	MergedAt                *time.Time             `json:"mergedAt"`
+	Author                  *graphQLActor          `json:"author"`
+	Assignees               graphQLUserConnection  `json:"assignees"`
+	Labels                  graphQLLabelConnection `json:"labels"`
+	ClosingIssuesReferences struct {
+		Nodes []*struct {
+			Number int `json:"number"`
+		} `json:"nodes"`
+	} `json:"closingIssuesReferences"`
+}
+
+type graphQLActor struct {
+	Login string `json:"login"`
+	URL   string `json:"url"`
+}
+
+type graphQLUserConnection struct {
+	Nodes []*struct {
+		DatabaseID int    `json:"databaseId"`
+		Login      string `json:"login"`
+		URL        string `json:"url"`
+	} `json:"nodes"`
+}
+
+type graphQLLabelConnection struct {
+	Nodes []*Label `json:"nodes"`
+}
@@ -0,0 +1,388 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	graphQLMilestonesQuery = `query($owner: String!, $name: String!, $states: [MilestoneState!], $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    milestones(first: 100, after: $cursor, states: $states) {
+      nodes {
+        databaseId
+        number
+        title
+        url
+        state
+        description
+        dueOn
+        openIssues: issues(states: OPEN) { totalCount }
+        closedIssues: issues(states: CLOSED) { totalCount }
+        openPullRequests: pullRequests(states: OPEN) { totalCount }
+        closedPullRequests: pullRequests(states: [CLOSED, MERGED]) { totalCount }
+      }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+  rateLimit { cost remaining }
+}`
+
+	graphQLMilestoneContentsQuery = `query($owner: String!, $name: String!, $number: Int!, $issueStates: [IssueState!], $pullRequestStates: [PullRequestState!], $includeIssues: Boolean!, $issuesCursor: String, $includePullRequests: Boolean!, $pullRequestsCursor: String) {
+  repository(owner: $owner, name: $name) {
+    milestone(number: $number) {
+      issues(first: 100, after: $issuesCursor, states: $issueStates) @include(if: $includeIssues) {
+        nodes {
+          databaseId
+          number
+          title
+          url
+          state
+          body
+          author { login url }
+          assignees(first: 1) { nodes { databaseId login url } }
+          labels(first: 100) { nodes { name color } }
+        }
+        pageInfo { hasNextPage endCursor }
+      }
+      pullRequests(first: 100, after: $pullRequestsCursor, states: $pullRequestStates) @include(if: $includePullRequests) {
+        nodes {
+          databaseId
+          number
+          title
+          url
+          state
+          body
+          merged
+          mergedAt
+          author { login url }
+          assignees(first: 1) { nodes { databaseId login url } }
+          labels(first: 100) { nodes { name color } }
+          closingIssuesReferences(first: 50) { nodes { number } }
+        }
+        pageInfo { hasNextPage endCursor }
+      }
+    }
+  }
+  rateLimit { cost remaining }
+}`
+)
+
+// graphQLAPIClientImpl retrieves milestones and their issues and pull requests with the graphql api in as few requests as possible; writes keep using the rest api
+type graphQLAPIClientImpl struct {
+	*apiClientImpl
+	graphQLURL string
+}
+
+// NewGraphQLAPIClient creates a client that uses the graphql api to retrieve milestones with their issues and pull requests and the rest api for everything else
+func NewGraphQLAPIClient(options Options) APIClient {
+	return &graphQLAPIClientImpl{
+		apiClientImpl: NewAPIClient(options).(*apiClientImpl),
+		graphQLURL:    getGraphQLURL(options.BaseURL),
+	}
+}
+
+// getGraphQLURL derives the graphql endpoint from the rest api base url, which differs between github.com and Github Enterprise
+func getGraphQLURL(baseURL string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	if strings.HasSuffix(baseURL, "/api/v3") {
+		return strings.TrimSuffix(baseURL, "/v3") + "/graphql"
+	}
+	return baseURL + "/graphql"
+}
+
+func (gh *graphQLAPIClientImpl) GetMilestoneByVersion(ctx context.Context, repoOwner, repoName, version string, lookup MilestoneLookup) (ms *Milestone, err error) {
+
+	log.Info().Msgf("Retrieving milestone for version %v with lookup %v...", version, lookup.Describe())
+
+	state := "open"
+	if lookup.IncludeClosed {
+		state = "all"
+	}
+
+	milestones, err := gh.GetMilestones(ctx, repoOwner, repoName, state)
+	if err != nil {
+		return
+	}
+
+	ms, err = findMilestone(milestones, version, lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Msgf("Retrieved %v milestone %v", ms.State, ms.Title)
+
+	return ms, nil
+}
+
+func (gh *graphQLAPIClientImpl) GetMilestones(ctx context.Context, repoOwner, repoName, state string) (milestones []*Milestone, err error) {
+
+	// https://docs.github.com/en/graphql/reference/objects#repository
+	variables := map[string]interface{}{
+		"owner": repoOwner,
+		"name":  repoName,
+	}
+	if state != "all" {
+		variables["states"] = []string{strings.ToUpper(state)}
+	}
+
+	milestones = make([]*Milestone, 0)
+	for {
+		var data graphQLMilestonesData
+		err = gh.callGithubGraphQLAPI(ctx, graphQLMilestonesQuery, variables, &data)
+		if err != nil {
+			return
+		}
+		if data.Repository == nil {
+			return milestones, fmt.Errorf("Repository %v/%v could not be found", repoOwner, repoName)
+		}
+
+		for _, m := range data.Repository.Milestones.Nodes {
+			milestones = append(milestones, m.toMilestone())
+		}
+
+		if !data.Repository.Milestones.PageInfo.HasNextPage {
+			break
+		}
+		variables["cursor"] = data.Repository.Milestones.PageInfo.EndCursor
+	}
+
+	return milestones, nil
+}
+
+func (gh *graphQLAPIClientImpl) GetIssuesAndPullRequestsForMilestone(ctx context.Context, repoOwner, repoName string, milestone Milestone) (issues []*Issue, pullRequests []*PullRequest, err error) {
+
+	log.Info().Msgf("Retrieving issues for milestone #%v...", milestone.Number)
+
+	issues, pullRequests, err = gh.getMilestoneContents(ctx, repoOwner, repoName, milestone, "closed")
+	if err != nil {
+		return
+	}
+
+	log.Info().Msgf("Retrieved %v issues and %v pull requests", len(issues), len(pullRequests))
+
+	return issues, pullRequests, nil
+}
+
+func (gh *graphQLAPIClientImpl) GetIssuesForMilestone(ctx context.Context, repoOwner, repoName string, milestone Milestone, state string) (issuesAndPullRequests []*Issue, err error) {
+
+	issues, pullRequests, err := gh.getMilestoneContents(ctx, repoOwner, repoName, milestone, state)
+	if err != nil {
+		return
+	}
+
+	// return pull requests as issues, like the rest api does
+	issuesAndPullRequests = issues
+	for _, pr := range pullRequests {
+		issuesAndPullRequests = append(issuesAndPullRequests, &Issue{
+			ID:          pr.ID,
+			Number:      pr.Number,
+			Title:       pr.Title,
+			HTMLURL:     pr.HTMLURL,
+			State:       pr.State,
+			Body:        pr.Body,
+			User:        pr.User,
+			Assignee:    pr.Assignee,
+			Milestone:   pr.Milestone,
+			Labels:      pr.Labels,
+			PullRequest: &IssuePullRequest{URL: pr.URL, HTMLURL: pr.HTMLURL},
+		})
+	}
+
+	return issuesAndPullRequests, nil
+}
+
+// getMilestoneContents pages through the issues and pull requests of a milestone in the same queries, until both are exhausted
+func (gh *graphQLAPIClientImpl) getMilestoneContents(ctx context.Context, repoOwner, repoName string, milestone Milestone, state string) (issues []*Issue, pullRequests []*PullRequest, err error) {
+
+	// https://docs.github.com/en/graphql/reference/objects#milestone
+	variables := map[string]interface{}{
+		"owner":               repoOwner,
+		"name":                repoName,
+		"number":              milestone.Number,
+		"includeIssues":       true,
+		"includePullRequests": true,
+	}
+	switch state {
+	case "open":
+		variables["issueStates"] = []string{"OPEN"}
+		variables["pullRequestStates"] = []string{"OPEN"}
+	case "closed":
+		variables["issueStates"] = []string{"CLOSED"}
+		variables["pullRequestStates"] = []string{"CLOSED", "MERGED"}
+	}
+
+	issues = make([]*Issue, 0)
+	pullRequests = make([]*PullRequest, 0)
+	for {
+		var data graphQLMilestoneContentsData
+		err = gh.callGithubGraphQLAPI(ctx, graphQLMilestoneContentsQuery, variables, &data)
+		if err != nil {
+			return
+		}
+		if data.Repository == nil || data.Repository.Milestone == nil {
+			return issues, pullRequests, fmt.Errorf("Milestone #%v could not be found in repository %v/%v", milestone.Number, repoOwner, repoName)
+		}
+
+		ms := data.Repository.Milestone
+		if ms.Issues != nil {
+			for _, i := range ms.Issues.Nodes {
+				issues = append(issues, i.toIssue(&milestone))
+			}
+			variables["includeIssues"] = ms.Issues.PageInfo.HasNextPage
+			variables["issuesCursor"] = ms.Issues.PageInfo.EndCursor
+		}
+		if ms.PullRequests != nil {
+			for _, pr := range ms.PullRequests.Nodes {
+				pullRequests = append(pullRequests, pr.toPullRequest(&milestone))
+			}
+			variables["includePullRequests"] = ms.PullRequests.PageInfo.HasNextPage
+			variables["pullRequestsCursor"] = ms.PullRequests.PageInfo.EndCursor
+		}
+
+		if !variables["includeIssues"].(bool) && !variables["includePullRequests"].(bool) {
+			break
+		}
+	}
+
+	return issues, pullRequests, nil
+}
+
+// callGithubGraphQLAPI runs a query and unmarshals its data; errors in the response fail the call, even though the status code is 200
+func (gh *graphQLAPIClientImpl) callGithubGraphQLAPI(ctx context.Context, query string, variables map[string]interface{}, data interface{}) (err error) {
+
+	// https://docs.github.com/en/graphql/guides/forming-calls-with-graphql; the client only runs queries, so they're safe to retry
+	body, err := gh.callGithubAPI(WithReadOnlyRequest(ctx), "POST", gh.graphQLURL, "application/json", []int{http.StatusOK}, graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return
+	}
+
+	var response graphQLResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return
+	}
+
+	if len(response.Errors) > 0 {
+		messages := make([]string, 0, len(response.Errors))
+		for _, e := range response.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("Github graphql api returned errors: %v", strings.Join(messages, "; "))
+	}
+
+	if response.Data == nil {
+		return fmt.Errorf("Github graphql api returned no data")
+	}
+
+	err = json.Unmarshal(response.Data, data)
+	if err != nil {
+		return
+	}
+
+	var rateLimit graphQLRateLimitData
+	if json.Unmarshal(response.Data, &rateLimit) == nil && rateLimit.RateLimit != nil {
+		log.Debug().Msgf("Graphql query cost %v points, %v remaining", rateLimit.RateLimit.Cost, rateLimit.RateLimit.Remaining)
+	}
+
+	return nil
+}
+
+func (m *graphQLMilestone) toMilestone() *Milestone {
+
+	var dueOn string
+	if m.DueOn != nil {
+		dueOn = m.DueOn.UTC().Format(time.RFC3339)
+	}
+
+	return &Milestone{
+		ID:           m.DatabaseID,
+		Number:       m.Number,
+		Title:        m.Title,
+		HTMLURL:      m.URL,
+		State:        strings.ToLower(m.State),
+		OpenIssues:   m.OpenIssues.TotalCount + m.OpenPullRequests.TotalCount,
+		ClosedIssues: m.ClosedIssues.TotalCount + m.ClosedPullRequests.TotalCount,
+		Description:  m.Description,
+		DueOn:        dueOn,
+	}
+}
+
+func (i *graphQLIssue) toIssue(milestone *Milestone) *Issue {
+	return &Issue{
+		ID:        i.DatabaseID,
+		Number:    i.Number,
+		Title:     i.Title,
+		HTMLURL:   i.URL,
+		State:     strings.ToLower(i.State),
+		Body:      i.Body,
+		User:      i.Author.toUser(),
+		Assignee:  i.Assignees.first(),
+		Milestone: milestone,
+		Labels:    i.Labels.toLabels(),
+	}
+}
+
+func (pr *graphQLPullRequest) toPullRequest(milestone *Milestone) *PullRequest {
+
+	// the rest api reports merged pull requests as closed
+	state := strings.ToLower(pr.State)
+	if pr.Merged {
+		state = "closed"
+	}
+
+	linkedIssueNumbers := make([]int, 0, len(pr.ClosingIssuesReferences.Nodes))
+	for _, i := range pr.ClosingIssuesReferences.Nodes {
+		linkedIssueNumbers = append(linkedIssueNumbers, i.Number)
+	}
+
+	return &PullRequest{
+		ID:                 pr.DatabaseID,
+		Number:             pr.Number,
+		Title:              pr.Title,
+		HTMLURL:            pr.URL,
+		State:              state,
+		Body:               pr.Body,
+		User:               pr.Author.toUser(),
+		Assignee:           pr.Assignees.first(),
+		Milestone:          milestone,
+		Labels:             pr.Labels.toLabels(),
+		Merged:             pr.Merged,
+		MergedAt:           pr.MergedAt,
+		LinkedIssueNumbers: linkedIssueNumbers,
+	}
+}
+
+func (a *graphQLActor) toUser() *User {
+	if a == nil {
+		return nil
+	}
+	return &User{
+		Login:   a.Login,
+		HTMLURL: a.URL,
+	}
+}
+
+func (c graphQLUserConnection) first() *User {
+	if len(c.Nodes) == 0 {
+		return nil
+	}
+	return &User{
+		ID:      c.Nodes[0].DatabaseID,
+		Login:   c.Nodes[0].Login,
+		HTMLURL: c.Nodes[0].URL,
+	}
+}
+
+func (c graphQLLabelConnection) toLabels() []*Label {
+	if c.Nodes == nil {
+		return make([]*Label, 0)
+	}
+	return c.Nodes
+}
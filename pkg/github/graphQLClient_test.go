@@ -0,0 +1,127 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetGithubGraphQLURL(t *testing.T) {
+
+	t.Run("AppendsGraphqlToPublicApi", func(t *testing.T) {
+
+		// act
+		url := getGraphQLURL("https://api.github.com")
+
+		assert.Equal(t, "https://api.github.com/graphql", url)
+	})
+
+	t.Run("ReplacesVersionForGithubEnterprise", func(t *testing.T) {
+
+		// act
+		url := getGraphQLURL("https://git.example.com/api/v3")
+
+		assert.Equal(t, "https://git.example.com/api/graphql", url)
+	})
+}
+
+func TestGithubGraphQLAPIClient(t *testing.T) {
+
+	t.Run("GetMilestoneByVersionFollowsCursor", func(t *testing.T) {
+
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/graphql", r.URL.Path)
+			var request graphQLRequest
+			body, _ := ioutil.ReadAll(r.Body)
+			_ = json.Unmarshal(body, &request)
+			requests++
+			if request.Variables["cursor"] == nil {
+				fmt.Fprint(w, `{"data":{"repository":{"milestones":{"nodes":[{"number":1,"title":"1.1.0","state":"OPEN"}],"pageInfo":{"hasNextPage":true,"endCursor":"abc"}}}}}`)
+				return
+			}
+			assert.Equal(t, "abc", request.Variables["cursor"])
+			fmt.Fprint(w, `{"data":{"repository":{"milestones":{"nodes":[{"number":2,"title":"1.2.0","state":"OPEN","url":"https://github.com/estafette/app/milestone/2","openIssues":{"totalCount":1},"openPullRequests":{"totalCount":2}}],"pageInfo":{"hasNextPage":false,"endCursor":"def"}}}}}`)
+		}))
+		defer server.Close()
+
+		client := NewGraphQLAPIClient(Options{BaseURL: server.URL, AccessToken: "secret", RequestTimeout: time.Minute})
+
+		// act
+		milestone, err := client.GetMilestoneByVersion(context.Background(), "estafette", "app", "1.2.0", MilestoneLookup{Strategy: MilestoneLookupExact})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, requests)
+		assert.Equal(t, 2, milestone.Number)
+		assert.Equal(t, "open", milestone.State)
+		assert.Equal(t, 3, milestone.OpenIssues)
+	})
+
+	t.Run("GetIssuesAndPullRequestsForMilestonePagesUntilBothConnectionsAreExhausted", func(t *testing.T) {
+
+		requests := make([]graphQLRequest, 0)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var request graphQLRequest
+			body, _ := ioutil.ReadAll(r.Body)
+			_ = json.Unmarshal(body, &request)
+			requests = append(requests, request)
+			if len(requests) == 1 {
+				fmt.Fprint(w, `{"data":{"repository":{"milestone":{
+					"issues":{"nodes":[{"number":3,"title":"Fix crash","state":"CLOSED","url":"https://github.com/estafette/app/issues/3","author":{"login":"reporter"},"labels":{"nodes":[{"name":"bug"}]}}],"pageInfo":{"hasNextPage":false,"endCursor":"i1"}},
+					"pullRequests":{"nodes":[{"number":4,"title":"Add retries","state":"MERGED","merged":true,"mergedAt":"2020-01-02T03:04:05Z","assignees":{"nodes":[{"login":"jorrit","url":"https://github.com/jorrit"}]},"closingIssuesReferences":{"nodes":[{"number":3}]}}],"pageInfo":{"hasNextPage":true,"endCursor":"p1"}}
+				}},"rateLimit":{"cost":1,"remaining":4999}}}`)
+				return
+			}
+			fmt.Fprint(w, `{"data":{"repository":{"milestone":{
+				"pullRequests":{"nodes":[{"number":5,"title":"Abandoned","state":"CLOSED","merged":false}],"pageInfo":{"hasNextPage":false,"endCursor":"p2"}}
+			}}}}`)
+		}))
+		defer server.Close()
+
+		client := NewGraphQLAPIClient(Options{BaseURL: server.URL, AccessToken: "secret", RequestTimeout: time.Minute})
+
+		// act
+		issues, pullRequests, err := client.GetIssuesAndPullRequestsForMilestone(context.Background(), "estafette", "app", Milestone{Number: 2, Title: "1.2.0"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(requests))
+		assert.Equal(t, false, requests[1].Variables["includeIssues"])
+		assert.Equal(t, "p1", requests[1].Variables["pullRequestsCursor"])
+
+		assert.Equal(t, 1, len(issues))
+		assert.Equal(t, "closed", issues[0].State)
+		assert.Equal(t, "reporter", issues[0].User.Login)
+		assert.Equal(t, "bug", issues[0].Labels[0].Name)
+
+		assert.Equal(t, 2, len(pullRequests))
+		assert.Equal(t, "closed", pullRequests[0].State)
+		assert.True(t, pullRequests[0].Merged)
+		assert.NotNil(t, pullRequests[0].MergedAt)
+		assert.Equal(t, "jorrit", pullRequests[0].Assignee.Login)
+		assert.Equal(t, []int{3}, pullRequests[0].LinkedIssueNumbers)
+		assert.False(t, pullRequests[1].Merged)
+	})
+
+	t.Run("ReturnsErrorsInResponse", func(t *testing.T) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"data":{"repository":{"milestone":null}},"errors":[{"type":"NOT_FOUND","message":"Could not resolve to a Milestone with the number of 9."}]}`)
+		}))
+		defer server.Close()
+
+		client := NewGraphQLAPIClient(Options{BaseURL: server.URL, AccessToken: "secret", RequestTimeout: time.Minute})
+
+		// act
+		_, _, err := client.GetIssuesAndPullRequestsForMilestone(context.Background(), "estafette", "app", Milestone{Number: 9})
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "Could not resolve to a Milestone")
+	})
+}
@@ -0,0 +1,177 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// strategies for MilestoneLookup
+const (
+	MilestoneLookupExact  = "exact"
+	MilestoneLookupSemver = "semver"
+	MilestoneLookupRegex  = "regex"
+	MilestoneLookupPrefix = "prefix"
+)
+
+// MilestoneLookup defines how the milestone for a release version is found
+type MilestoneLookup struct {
+	Strategy      string
+	Pattern       string
+	IncludeClosed bool
+}
+
+// findMilestone returns the single milestone matching the version according to the lookup strategy; multiple matches are an error
+func findMilestone(milestones []*Milestone, version string, lookup MilestoneLookup) (*Milestone, error) {
+
+	titles := make([]string, 0, len(milestones))
+	states := make([]string, 0, len(milestones))
+	for _, m := range milestones {
+		titles = append(titles, m.Title)
+		states = append(states, m.State)
+	}
+
+	index, err := MatchMilestoneTitle(titles, states, version, lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	return milestones[index], nil
+}
+
+// MatchMilestoneTitle returns the index of the single milestone title matching the version, independent of the provider's milestone type
+func MatchMilestoneTitle(titles, states []string, version string, lookup MilestoneLookup) (int, error) {
+
+	err := lookup.Validate()
+	if err != nil {
+		return -1, err
+	}
+
+	matches := make([]int, 0)
+
+	switch lookup.Strategy {
+	case "", MilestoneLookupExact:
+		for i, title := range titles {
+			if title == version {
+				matches = append(matches, i)
+			}
+		}
+
+	case MilestoneLookupSemver:
+		normalizedVersion := NormalizeVersion(version)
+		for i, title := range titles {
+			if NormalizeVersion(title) == normalizedVersion {
+				matches = append(matches, i)
+			}
+		}
+
+	case MilestoneLookupRegex:
+		pattern := lookup.Pattern
+		if pattern == "" {
+			pattern = "^" + regexp.QuoteMeta(version) + "$"
+		}
+		re := regexp.MustCompile(pattern)
+		for i, title := range titles {
+			if re.MatchString(title) {
+				matches = append(matches, i)
+			}
+		}
+
+	case MilestoneLookupPrefix:
+		prefix := lookup.Pattern
+		if prefix == "" {
+			prefix = version
+		}
+		for i, title := range titles {
+			if strings.HasPrefix(title, prefix) {
+				matches = append(matches, i)
+			}
+		}
+
+	}
+
+	if len(matches) == 0 {
+		return -1, &MilestoneNotFoundError{Version: version, Lookup: lookup}
+	}
+	if len(matches) > 1 {
+		matchingTitles := make([]string, 0, len(matches))
+		for _, i := range matches {
+			matchingTitles = append(matchingTitles, fmt.Sprintf("%v (%v)", titles[i], states[i]))
+		}
+		return -1, fmt.Errorf("Multiple milestones for version %v match lookup %v: %v; use a more specific lookup", version, lookup.Describe(), strings.Join(matchingTitles, ", "))
+	}
+
+	return matches[0], nil
+}
+
+// Validate checks the strategy and the regular expression of a regex lookup, so they can be reported before looking for the milestone
+func (l MilestoneLookup) Validate() error {
+
+	switch l.Strategy {
+	case "", MilestoneLookupExact, MilestoneLookupSemver, MilestoneLookupPrefix:
+	case MilestoneLookupRegex:
+		if l.Pattern != "" {
+			if _, err := regexp.Compile(l.Pattern); err != nil {
+				return fmt.Errorf("Milestone pattern %v is not a valid regular expression: %v", l.Pattern, err)
+			}
+		}
+	default:
+		return fmt.Errorf("Milestone lookup %v is not supported, use %v, %v, %v or %v", l.Strategy, MilestoneLookupExact, MilestoneLookupSemver, MilestoneLookupRegex, MilestoneLookupPrefix)
+	}
+
+	return nil
+}
+
+// Describe returns the strategy with its pattern for log and error messages
+func (l MilestoneLookup) Describe() string {
+	strategy := l.Strategy
+	if strategy == "" {
+		strategy = MilestoneLookupExact
+	}
+	description := strategy
+	if l.Pattern != "" {
+		description += fmt.Sprintf(" '%v'", l.Pattern)
+	}
+	if l.IncludeClosed {
+		description += " including closed milestones"
+	}
+	return description
+}
+
+// MilestoneNotFoundError is returned when no milestone matches the version, as opposed to a lookup that's invalid, ambiguous or failed to retrieve the milestones
+type MilestoneNotFoundError struct {
+	Version string
+	Lookup  MilestoneLookup
+}
+
+func (e *MilestoneNotFoundError) Error() string {
+	return fmt.Sprintf("No milestone for version %v could be found with lookup %v", e.Version, e.Lookup.Describe())
+}
+
+// IsMilestoneNotFoundError returns true if no milestone matches the version, which the missing milestone policy may ignore or resolve by creating it
+func IsMilestoneNotFoundError(err error) bool {
+	var notFoundError *MilestoneNotFoundError
+	return errors.As(err, &notFoundError)
+}
+
+// NormalizeVersion strips a leading v and adds missing minor and patch numbers, so v1.2 and 1.2.0 are considered equal
+func NormalizeVersion(version string) string {
+
+	version = strings.TrimSpace(version)
+	version = strings.TrimPrefix(strings.TrimPrefix(version, "v"), "V")
+
+	// keep pre-release and build metadata as is
+	suffix := ""
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		suffix = version[i:]
+		version = version[:i]
+	}
+
+	parts := strings.Split(version, ".")
+	for len(parts) < 3 {
+		parts = append(parts, "0")
+	}
+
+	return strings.Join(parts, ".") + suffix
+}
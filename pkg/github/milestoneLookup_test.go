@@ -0,0 +1,106 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindMilestone(t *testing.T) {
+
+	milestones := []*Milestone{
+		{Number: 1, Title: "1.1.0", State: "closed"},
+		{Number: 2, Title: "v1.2", State: "open"},
+		{Number: 3, Title: "1.3.0", State: "open"},
+		{Number: 4, Title: "1.3.1", State: "open"},
+	}
+
+	t.Run("ReturnsMilestoneWithIdenticalTitleForExactLookup", func(t *testing.T) {
+
+		// act
+		milestone, err := findMilestone(milestones, "1.3.0", MilestoneLookup{Strategy: MilestoneLookupExact})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 3, milestone.Number)
+	})
+
+	t.Run("ReturnsErrorIfNoTitleIsIdenticalForExactLookup", func(t *testing.T) {
+
+		// act
+		_, err := findMilestone(milestones, "1.2.0", MilestoneLookup{Strategy: MilestoneLookupExact})
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReturnsMilestoneWithLeadingVAndMissingPatchForSemverLookup", func(t *testing.T) {
+
+		// act
+		milestone, err := findMilestone(milestones, "1.2.0", MilestoneLookup{Strategy: MilestoneLookupSemver})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, milestone.Number)
+	})
+
+	t.Run("ReturnsMilestoneMatchingPatternForRegexLookup", func(t *testing.T) {
+
+		// act
+		milestone, err := findMilestone(milestones, "1.1.0", MilestoneLookup{Strategy: MilestoneLookupRegex, Pattern: "^1\\.1\\."})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, milestone.Number)
+	})
+
+	t.Run("ReturnsErrorIfMultipleMilestonesMatchForPrefixLookup", func(t *testing.T) {
+
+		// act
+		_, err := findMilestone(milestones, "1.3", MilestoneLookup{Strategy: MilestoneLookupPrefix})
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "1.3.0 (open), 1.3.1 (open)")
+		assert.False(t, IsMilestoneNotFoundError(err))
+	})
+
+	t.Run("ReturnsErrorForInvalidPatternForRegexLookup", func(t *testing.T) {
+
+		// act
+		_, err := findMilestone(milestones, "1.3.0", MilestoneLookup{Strategy: MilestoneLookupRegex, Pattern: "1.3.("})
+
+		assert.NotNil(t, err)
+		assert.False(t, IsMilestoneNotFoundError(err))
+	})
+
+	t.Run("ReturnsErrorForUnsupportedStrategy", func(t *testing.T) {
+
+		// act
+		_, err := findMilestone(milestones, "1.3.0", MilestoneLookup{Strategy: "fuzzy"})
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestNormalizeVersion(t *testing.T) {
+
+	t.Run("StripsLeadingV", func(t *testing.T) {
+
+		// act
+		version := NormalizeVersion("v1.2.0")
+
+		assert.Equal(t, "1.2.0", version)
+	})
+
+	t.Run("AddsMissingPatch", func(t *testing.T) {
+
+		// act
+		version := NormalizeVersion("1.2")
+
+		assert.Equal(t, "1.2.0", version)
+	})
+
+	t.Run("KeepsPreReleaseLabel", func(t *testing.T) {
+
+		// act
+		version := NormalizeVersion("v1.2-beta.1")
+
+		assert.Equal(t, "1.2.0-beta.1", version)
+	})
+}
@@ -0,0 +1,70 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// NewProviderHTTPClient creates the client for the api of Gitlab or Gitea with the same middleware as the Github client; the provider authenticates with its own headers, so the token and cache, which is keyed on Github's authorization header, are left out
+func NewProviderHTTPClient(options Options) *http.Client {
+	options.AccessToken = ""
+	options.Cache = nil
+	options.Headers = http.Header{}
+	if options.UserAgent != "" {
+		options.Headers.Set("User-Agent", options.UserAgent)
+	}
+	return NewHTTPClient(options)
+}
+
+// CallProviderAPI performs a request against the api of Gitlab or Gitea with a client from NewProviderHTTPClient, the headers carry its authentication
+func CallProviderAPI(ctx context.Context, client *http.Client, requestTimeout time.Duration, method, url string, headers map[string]string, contentType string, validStatusCodes []int, requestBody []byte) (body []byte, header http.Header, err error) {
+
+	if requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, requestTimeout)
+		defer cancel()
+	}
+
+	var request *http.Request
+	if requestBody != nil {
+		request, err = http.NewRequestWithContext(ctx, method, url, bytes.NewReader(requestBody))
+	} else {
+		request, err = http.NewRequestWithContext(ctx, method, url, nil)
+	}
+	if err != nil {
+		return
+	}
+
+	for k, v := range headers {
+		request.Header.Set(k, v)
+	}
+	request.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return
+	}
+
+	defer response.Body.Close()
+
+	header = response.Header
+
+	body, err = ioutil.ReadAll(response.Body)
+	if err != nil {
+		return
+	}
+
+	for _, sc := range validStatusCodes {
+		if response.StatusCode == sc {
+			return body, header, nil
+		}
+	}
+
+	return body, header, NewAPIError(method, url, response.StatusCode, header, body)
+}
@@ -0,0 +1,214 @@
+package github
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ResponseCache stores responses with an etag on disk, so reruns and later stages can make conditional requests, see https://developer.github.com/v3/#conditional-requests
+type ResponseCache struct {
+	directory string
+	mutex     sync.Mutex
+}
+
+// responseCacheEntry is the json file stored in the cache directory for each cached response
+type responseCacheEntry struct {
+	URL        string      `json:"url"`
+	ETag       string      `json:"etag"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// NewResponseCache creates the directory if needed and caches responses in it
+func NewResponseCache(directory string) (*ResponseCache, error) {
+	err := os.MkdirAll(directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &ResponseCache{
+		directory: directory,
+	}, nil
+}
+
+// key identifies a response by its url and the headers that change its content, including the authorization so responses aren't shared between tokens
+func (c *ResponseCache) key(request *http.Request) string {
+	hash := sha256.New()
+	hash.Write([]byte(request.URL.String()))
+	hash.Write([]byte(strings.Join(request.Header["Accept"], ",")))
+	hash.Write([]byte(request.Header.Get("Authorization")))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+var (
+	// relatedResources groups the resources of a repository that change together, so a write to one invalidates the cached reads of the others; a pull request is an issue with a milestone, and a release creates a tag
+	relatedResources = map[string]string{
+		"issues":     "issues",
+		"pulls":      "issues",
+		"milestones": "issues",
+		"labels":     "issues",
+		"git":        "refs",
+		"tags":       "refs",
+		"releases":   "refs",
+	}
+)
+
+// resourceDirectory returns the directory with the entries of a repository's group of related resources, whatever host or path serves them, like /git/ref/tags/v1 and /git/refs/tags/v1 or the uploads host for release assets
+func (c *ResponseCache) resourceDirectory(u *url.URL) string {
+	segments := strings.Split(path.Clean("/"+u.Path), "/")
+	for i, segment := range segments {
+		if segment != "repos" || i+2 >= len(segments) {
+			continue
+		}
+		resource := ""
+		if i+3 < len(segments) {
+			resource = segments[i+3]
+			if group, ok := relatedResources[resource]; ok {
+				resource = group
+			}
+		}
+		return filepath.Join(c.directory, "repos", url.PathEscape(strings.ToLower(segments[i+1])), url.PathEscape(strings.ToLower(segments[i+2])), url.PathEscape(resource))
+	}
+	return filepath.Join(c.directory, url.PathEscape(u.Host))
+}
+
+// file returns the path of the cache entry for the request
+func (c *ResponseCache) file(request *http.Request) string {
+	return filepath.Join(c.resourceDirectory(request.URL), c.key(request)+".json")
+}
+
+func (c *ResponseCache) get(file string) (entry *responseCacheEntry, ok bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, false
+	}
+	err = json.Unmarshal(data, &entry)
+	if err != nil {
+		return nil, false
+	}
+
+	return entry, true
+}
+
+func (c *ResponseCache) put(file string, entry responseCacheEntry) (err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	err = os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return
+	}
+
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// invalidate removes all entries for the group of resources a write request changed, since the same resource is often read and written with different urls
+func (c *ResponseCache) invalidate(request *http.Request) (err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return os.RemoveAll(c.resourceDirectory(request.URL))
+}
+
+// cacheMiddleware makes get requests conditional on the etag of a cached response and serves the cached response when Github returns 304 Not Modified; it should go below the headers middleware so its key includes all headers
+func cacheMiddleware(cache *ResponseCache, metrics *Metrics) middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		if cache == nil {
+			return next
+		}
+		return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+
+			if request.Method != "GET" {
+				response, err := next.RoundTrip(request)
+				if err == nil && response.StatusCode < 400 && !isReadOnlyRequest(request) {
+					if invalidateErr := cache.invalidate(request); invalidateErr != nil {
+						log.Warn().Err(invalidateErr).Msgf("Invalidating cached responses for '%v %v' failed", request.Method, redactURL(request.URL))
+					}
+				}
+				return response, err
+			}
+
+			file := cache.file(request)
+			entry, ok := cache.get(file)
+			if ok {
+				request = request.Clone(request.Context())
+				request.Header.Set("If-None-Match", entry.ETag)
+			}
+
+			response, err := next.RoundTrip(request)
+			if err != nil {
+				return response, err
+			}
+
+			if ok && response.StatusCode == http.StatusNotModified {
+				response.Body.Close()
+				metrics.recordCacheHit()
+
+				// keep the fresh rate limit headers, the cached ones are outdated
+				header := entry.Header.Clone()
+				for name, values := range response.Header {
+					if strings.HasPrefix(name, "X-Ratelimit-") {
+						header[name] = values
+					}
+				}
+
+				return &http.Response{
+					Status:        http.StatusText(entry.StatusCode),
+					StatusCode:    entry.StatusCode,
+					Proto:         response.Proto,
+					ProtoMajor:    response.ProtoMajor,
+					ProtoMinor:    response.ProtoMinor,
+					Header:        header,
+					Body:          ioutil.NopCloser(bytes.NewReader(entry.Body)),
+					ContentLength: int64(len(entry.Body)),
+					Request:       request,
+				}, nil
+			}
+
+			etag := response.Header.Get("ETag")
+			if response.StatusCode != http.StatusOK || etag == "" {
+				return response, nil
+			}
+
+			body, err := ioutil.ReadAll(response.Body)
+			response.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			response.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			err = cache.put(file, responseCacheEntry{
+				URL:        request.URL.String(),
+				ETag:       etag,
+				StatusCode: response.StatusCode,
+				Header:     response.Header,
+				Body:       body,
+			})
+			if err != nil {
+				log.Warn().Err(err).Msgf("Caching response for '%v %v' failed", request.Method, redactURL(request.URL))
+			}
+
+			return response, nil
+		})
+	}
+}
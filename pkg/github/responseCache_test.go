@@ -0,0 +1,233 @@
+package github
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newEtagServer serves a body per path with an etag that changes whenever the body is changed by a write
+func newEtagServer(bodies map[string]string, requests *int, conditionalRequests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.Method != "GET" {
+			bodies[r.URL.Path] = "changed"
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		etag := `"` + bodies[r.URL.Path] + `"`
+		if r.Header.Get("If-None-Match") != "" {
+			*conditionalRequests++
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(bodies[r.URL.Path]))
+	}))
+}
+
+func TestCacheMiddleware(t *testing.T) {
+
+	t.Run("ServesNotModifiedResponseFromCache", func(t *testing.T) {
+
+		directory, _ := ioutil.TempDir("", "cache")
+		defer os.RemoveAll(directory)
+		cache, _ := NewResponseCache(directory)
+
+		requests, conditionalRequests := 0, 0
+		server := newEtagServer(map[string]string{"/repos/estafette/app/milestones": "milestones"}, &requests, &conditionalRequests)
+		defer server.Close()
+
+		metrics := &Metrics{}
+		client := &http.Client{Transport: chainMiddleware(http.DefaultTransport, cacheMiddleware(cache, metrics))}
+
+		_, err := client.Get(server.URL + "/repos/estafette/app/milestones")
+		assert.Nil(t, err)
+
+		// act
+		response, err := client.Get(server.URL + "/repos/estafette/app/milestones")
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		body, _ := ioutil.ReadAll(response.Body)
+		assert.Equal(t, "milestones", string(body))
+		assert.Equal(t, 2, requests)
+		assert.Equal(t, 1, conditionalRequests)
+		assert.Equal(t, 1, metrics.CacheHits)
+	})
+
+	t.Run("SharesCacheBetweenClientsUsingTheSameDirectory", func(t *testing.T) {
+
+		directory, _ := ioutil.TempDir("", "cache")
+		defer os.RemoveAll(directory)
+
+		requests, conditionalRequests := 0, 0
+		server := newEtagServer(map[string]string{"/repos/estafette/app/issues": "issues"}, &requests, &conditionalRequests)
+		defer server.Close()
+
+		firstCache, _ := NewResponseCache(directory)
+		firstClient := &http.Client{Transport: chainMiddleware(http.DefaultTransport, cacheMiddleware(firstCache, nil))}
+		_, err := firstClient.Get(server.URL + "/repos/estafette/app/issues")
+		assert.Nil(t, err)
+
+		secondCache, _ := NewResponseCache(directory)
+		secondClient := &http.Client{Transport: chainMiddleware(http.DefaultTransport, cacheMiddleware(secondCache, nil))}
+
+		// act
+		response, err := secondClient.Get(server.URL + "/repos/estafette/app/issues")
+
+		assert.Nil(t, err)
+		body, _ := ioutil.ReadAll(response.Body)
+		assert.Equal(t, "issues", string(body))
+		assert.Equal(t, 1, conditionalRequests)
+	})
+
+	t.Run("DoesNotShareResponsesBetweenTokens", func(t *testing.T) {
+
+		directory, _ := ioutil.TempDir("", "cache")
+		defer os.RemoveAll(directory)
+		cache, _ := NewResponseCache(directory)
+
+		requests, conditionalRequests := 0, 0
+		server := newEtagServer(map[string]string{"/repos/estafette/app": "repository"}, &requests, &conditionalRequests)
+		defer server.Close()
+
+		firstClient := &http.Client{Transport: chainMiddleware(http.DefaultTransport, authMiddleware("token", "first"), cacheMiddleware(cache, nil))}
+		_, err := firstClient.Get(server.URL + "/repos/estafette/app")
+		assert.Nil(t, err)
+
+		secondClient := &http.Client{Transport: chainMiddleware(http.DefaultTransport, authMiddleware("token", "second"), cacheMiddleware(cache, nil))}
+
+		// act
+		_, err = secondClient.Get(server.URL + "/repos/estafette/app")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, conditionalRequests)
+	})
+
+	t.Run("InvalidatesItemAndListAfterWriteToItem", func(t *testing.T) {
+
+		directory, _ := ioutil.TempDir("", "cache")
+		defer os.RemoveAll(directory)
+		cache, _ := NewResponseCache(directory)
+
+		requests, conditionalRequests := 0, 0
+		server := newEtagServer(map[string]string{"/repos/estafette/app/milestones": "milestones", "/repos/estafette/app/milestones/3": "milestone"}, &requests, &conditionalRequests)
+		defer server.Close()
+
+		client := &http.Client{Transport: chainMiddleware(http.DefaultTransport, cacheMiddleware(cache, nil))}
+		client.Get(server.URL + "/repos/estafette/app/milestones")
+		client.Get(server.URL + "/repos/estafette/app/milestones/3")
+
+		request, _ := http.NewRequest("PATCH", server.URL+"/repos/estafette/app/milestones/3", strings.NewReader(`{"state":"closed"}`))
+		_, err := client.Do(request)
+		assert.Nil(t, err)
+
+		// act
+		client.Get(server.URL + "/repos/estafette/app/milestones")
+		response, err := client.Get(server.URL + "/repos/estafette/app/milestones/3")
+
+		assert.Nil(t, err)
+		body, _ := ioutil.ReadAll(response.Body)
+		assert.Equal(t, "changed", string(body))
+		assert.Equal(t, 0, conditionalRequests)
+	})
+
+	t.Run("InvalidatesTagRefAfterWriteWithOtherPath", func(t *testing.T) {
+
+		directory, _ := ioutil.TempDir("", "cache")
+		defer os.RemoveAll(directory)
+		cache, _ := NewResponseCache(directory)
+
+		requests, conditionalRequests := 0, 0
+		server := newEtagServer(map[string]string{"/repos/estafette/app/git/ref/tags/v1.0.0": "ref"}, &requests, &conditionalRequests)
+		defer server.Close()
+
+		client := &http.Client{Transport: chainMiddleware(http.DefaultTransport, cacheMiddleware(cache, nil))}
+		client.Get(server.URL + "/repos/estafette/app/git/ref/tags/v1.0.0")
+
+		request, _ := http.NewRequest("DELETE", server.URL+"/repos/estafette/app/git/refs/tags/v1.0.0", nil)
+		_, err := client.Do(request)
+		assert.Nil(t, err)
+
+		// act
+		_, err = client.Get(server.URL + "/repos/estafette/app/git/ref/tags/v1.0.0")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, conditionalRequests)
+	})
+
+	t.Run("InvalidatesReleaseAfterUploadToOtherHost", func(t *testing.T) {
+
+		directory, _ := ioutil.TempDir("", "cache")
+		defer os.RemoveAll(directory)
+		cache, _ := NewResponseCache(directory)
+
+		requests, conditionalRequests := 0, 0
+		apiServer := newEtagServer(map[string]string{"/repos/estafette/app/releases/tags/v1.0.0": "release"}, &requests, &conditionalRequests)
+		defer apiServer.Close()
+		uploadServer := newEtagServer(map[string]string{}, &requests, &conditionalRequests)
+		defer uploadServer.Close()
+
+		client := &http.Client{Transport: chainMiddleware(http.DefaultTransport, cacheMiddleware(cache, nil))}
+		client.Get(apiServer.URL + "/repos/estafette/app/releases/tags/v1.0.0")
+
+		request, _ := http.NewRequest("POST", uploadServer.URL+"/repos/estafette/app/releases/1/assets?name=app.zip", strings.NewReader("zip"))
+		_, err := client.Do(request)
+		assert.Nil(t, err)
+
+		// act
+		_, err = client.Get(apiServer.URL + "/repos/estafette/app/releases/tags/v1.0.0")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, conditionalRequests)
+	})
+
+	t.Run("KeepsCacheForOtherResourcesAfterWrite", func(t *testing.T) {
+
+		directory, _ := ioutil.TempDir("", "cache")
+		defer os.RemoveAll(directory)
+		cache, _ := NewResponseCache(directory)
+
+		requests, conditionalRequests := 0, 0
+		server := newEtagServer(map[string]string{"/repos/estafette/app/issues": "issues"}, &requests, &conditionalRequests)
+		defer server.Close()
+
+		client := &http.Client{Transport: chainMiddleware(http.DefaultTransport, cacheMiddleware(cache, nil))}
+		client.Get(server.URL + "/repos/estafette/app/issues")
+
+		request, _ := http.NewRequest("POST", server.URL+"/repos/estafette/app/releases", strings.NewReader(`{"tag_name":"v1.0.0"}`))
+		_, err := client.Do(request)
+		assert.Nil(t, err)
+
+		// act
+		_, err = client.Get(server.URL + "/repos/estafette/app/issues")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, conditionalRequests)
+	})
+
+	t.Run("PassesRequestsThroughWithoutCache", func(t *testing.T) {
+
+		requests, conditionalRequests := 0, 0
+		server := newEtagServer(map[string]string{"/repos/estafette/app": "repository"}, &requests, &conditionalRequests)
+		defer server.Close()
+
+		client := &http.Client{Transport: chainMiddleware(http.DefaultTransport, cacheMiddleware(nil, nil))}
+		client.Get(server.URL + "/repos/estafette/app")
+
+		// act
+		_, err := client.Get(server.URL + "/repos/estafette/app")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, requests)
+		assert.Equal(t, 0, conditionalRequests)
+	})
+}
@@ -0,0 +1,77 @@
+package github
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// TLSOptions configure trust and client certificates for Github Enterprise servers behind an internal certificate authority or a proxy requiring mtls
+type TLSOptions struct {
+	CABundle          []byte
+	ClientCertificate []byte
+	ClientKey         []byte
+}
+
+// WithFiles combines the pem encoded options, like those injected as credentials, with the pem files at the paths; the ca bundles are combined, a client certificate from files takes precedence
+func (o TLSOptions) WithFiles(caBundlePath, clientCertificatePath, clientKeyPath string) (options TLSOptions, err error) {
+
+	options = o
+	if caBundlePath != "" {
+		caBundle, err := ioutil.ReadFile(caBundlePath)
+		if err != nil {
+			return options, fmt.Errorf("Reading ca bundle %v failed: %w", caBundlePath, err)
+		}
+		options.CABundle = append(append(append([]byte{}, o.CABundle...), '\n'), caBundle...)
+	}
+
+	if clientCertificatePath != "" || clientKeyPath != "" {
+		if clientCertificatePath == "" || clientKeyPath == "" {
+			return options, fmt.Errorf("Parameters clientCertificatePath and clientKeyPath have to be set together")
+		}
+		options.ClientCertificate, err = ioutil.ReadFile(clientCertificatePath)
+		if err != nil {
+			return options, fmt.Errorf("Reading client certificate %v failed: %w", clientCertificatePath, err)
+		}
+		options.ClientKey, err = ioutil.ReadFile(clientKeyPath)
+		if err != nil {
+			return options, fmt.Errorf("Reading client key %v failed: %w", clientKeyPath, err)
+		}
+	}
+
+	return options, nil
+}
+
+// NewBaseTransport creates the transport at the bottom of the middleware chain, using the proxy from the HTTPS_PROXY and NO_PROXY environment variables and trusting the extra certificate authorities on top of the system ones
+func NewBaseTransport(options TLSOptions) (transport *http.Transport, err error) {
+
+	transport = http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+
+	tlsConfig := &tls.Config{}
+
+	if len(options.CABundle) > 0 {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil || rootCAs == nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(options.CABundle) {
+			return nil, fmt.Errorf("The ca bundle contains no pem encoded certificates")
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if len(options.ClientCertificate) > 0 || len(options.ClientKey) > 0 {
+		certificate, err := tls.X509KeyPair(options.ClientCertificate, options.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("Loading client certificate failed: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
@@ -0,0 +1,187 @@
+package github
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// generateClientCertificate creates a self-signed certificate for client authentication and returns it with its key in pem encoding
+func generateClientCertificate(t *testing.T) (certificatePEM, keyPEM []byte, certificate *x509.Certificate) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "estafette"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	certificate, err = x509.ParseCertificate(der)
+	assert.Nil(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), certificate
+}
+
+func TestNewBaseTransport(t *testing.T) {
+
+	t.Run("TrustsServerSignedByCABundle", func(t *testing.T) {
+
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		transport, err := NewBaseTransport(TLSOptions{
+			CABundle: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}),
+		})
+		assert.Nil(t, err)
+		client := &http.Client{Transport: transport}
+
+		// act
+		response, err := client.Get(server.URL)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	})
+
+	t.Run("RejectsServerWithUnknownCertificateAuthority", func(t *testing.T) {
+
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		transport, err := NewBaseTransport(TLSOptions{})
+		assert.Nil(t, err)
+		client := &http.Client{Transport: transport}
+
+		// act
+		_, err = client.Get(server.URL)
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("SendsClientCertificate", func(t *testing.T) {
+
+		certificatePEM, keyPEM, certificate := generateClientCertificate(t)
+		clientCAs := x509.NewCertPool()
+		clientCAs.AddCert(certificate)
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		server.TLS = &tls.Config{
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  clientCAs,
+		}
+		server.StartTLS()
+		defer server.Close()
+
+		transport, err := NewBaseTransport(TLSOptions{
+			CABundle:          pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}),
+			ClientCertificate: certificatePEM,
+			ClientKey:         keyPEM,
+		})
+		assert.Nil(t, err)
+		client := &http.Client{Transport: transport}
+
+		// act
+		response, err := client.Get(server.URL)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	})
+
+	t.Run("ReturnsErrorForCABundleWithoutCertificates", func(t *testing.T) {
+
+		// act
+		_, err := NewBaseTransport(TLSOptions{CABundle: []byte("not a certificate")})
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReturnsErrorForClientCertificateWithoutKey", func(t *testing.T) {
+
+		certificatePEM, _, _ := generateClientCertificate(t)
+
+		// act
+		_, err := NewBaseTransport(TLSOptions{ClientCertificate: certificatePEM})
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("UsesProxyFromEnvironment", func(t *testing.T) {
+
+		// act
+		transport, err := NewBaseTransport(TLSOptions{})
+
+		assert.Nil(t, err)
+		assert.NotNil(t, transport.Proxy)
+	})
+}
+
+func TestTLSOptionsWithFiles(t *testing.T) {
+
+	t.Run("CombinesCABundlesFromOptionsAndFiles", func(t *testing.T) {
+
+		directory, _ := ioutil.TempDir("", "tls")
+		defer os.RemoveAll(directory)
+		ioutil.WriteFile(filepath.Join(directory, "ca.pem"), []byte("from params"), 0644)
+
+		// act
+		options, err := TLSOptions{CABundle: []byte("from credentials")}.WithFiles(filepath.Join(directory, "ca.pem"), "", "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "from credentials\nfrom params", string(options.CABundle))
+	})
+
+	t.Run("PrefersClientCertificateFromFiles", func(t *testing.T) {
+
+		directory, _ := ioutil.TempDir("", "tls")
+		defer os.RemoveAll(directory)
+		ioutil.WriteFile(filepath.Join(directory, "client.pem"), []byte("certificate from params"), 0644)
+		ioutil.WriteFile(filepath.Join(directory, "client-key.pem"), []byte("key from params"), 0644)
+
+		// act
+		options, err := TLSOptions{ClientCertificate: []byte("certificate from credentials"), ClientKey: []byte("key from credentials")}.WithFiles("", filepath.Join(directory, "client.pem"), filepath.Join(directory, "client-key.pem"))
+
+		assert.Nil(t, err)
+		assert.Equal(t, "certificate from params", string(options.ClientCertificate))
+		assert.Equal(t, "key from params", string(options.ClientKey))
+	})
+
+	t.Run("ReturnsErrorWhenOnlyClientCertificatePathIsSet", func(t *testing.T) {
+
+		// act
+		_, err := TLSOptions{}.WithFiles("", "/certs/client.pem", "")
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReturnsErrorWhenCABundleDoesNotExist", func(t *testing.T) {
+
+		// act
+		_, err := TLSOptions{}.WithFiles("/does/not/exist.pem", "", "")
+
+		assert.NotNil(t, err)
+	})
+}
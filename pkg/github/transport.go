@@ -0,0 +1,411 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// githubAPIVersion is the rest api version requested from Github, see https://docs.github.com/en/rest/overview/api-versions
+	githubAPIVersion = "2022-11-28"
+
+	// maxRateLimitWait is the longest the rate limit middleware waits for the rate limit to reset before sending a request anyway
+	maxRateLimitWait = 5 * time.Minute
+)
+
+// middleware wraps a round tripper to add a single concern to all requests, like authentication or retries
+type middleware func(next http.RoundTripper) http.RoundTripper
+
+// roundTripperFunc turns a function into a http.RoundTripper
+type roundTripperFunc func(request *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+// chainMiddleware wraps the base round tripper in the middlewares, the first one being the outermost
+func chainMiddleware(base http.RoundTripper, middlewares ...middleware) http.RoundTripper {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		base = middlewares[i](base)
+	}
+	return base
+}
+
+// authMiddleware sets the authorization header, unless the request already has one
+func authMiddleware(scheme, token string) middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			if token == "" || request.Header.Get("Authorization") != "" {
+				return next.RoundTrip(request)
+			}
+			request = request.Clone(request.Context())
+			request.Header.Set("Authorization", fmt.Sprintf("%v %v", scheme, token))
+			return next.RoundTrip(request)
+		})
+	}
+}
+
+// headersMiddleware adds headers to every request that doesn't set them itself, like the user agent and the api version
+func headersMiddleware(headers http.Header) middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			request = request.Clone(request.Context())
+			for name, values := range headers {
+				if request.Header.Get(name) != "" {
+					continue
+				}
+				for _, v := range values {
+					request.Header.Add(name, v)
+				}
+			}
+			return next.RoundTrip(request)
+		})
+	}
+}
+
+// getGithubHeaders returns the headers sent with each request to the Github api
+func getGithubHeaders(userAgent string) http.Header {
+	headers := http.Header{}
+	headers.Set("User-Agent", userAgent)
+	headers.Set("X-GitHub-Api-Version", githubAPIVersion)
+	headers.Add("Accept", "application/vnd.github.machine-man-preview+json")
+	headers.Add("Accept", "application/vnd.github.groot-preview+json")
+	headers.Add("Accept", "application/vnd.github.antiope-preview+json")
+	return headers
+}
+
+// exponentialJitterBackoff waits 1, 2, 4, ... seconds with up to a second of jitter between attempts
+func exponentialJitterBackoff(attempt int) time.Duration {
+	return time.Duration(1<<uint(attempt))*time.Second + time.Duration(rand.Intn(1000))*time.Millisecond
+}
+
+// retryMiddleware retries requests that failed on the network or with a server error, as long as that's safe for the method, the body can be replayed and the context isn't done
+func retryMiddleware(maxRetries int, backoff func(attempt int) time.Duration, metrics *Metrics) middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(request *http.Request) (response *http.Response, err error) {
+			attemptRequest := request
+			for attempt := 0; ; attempt++ {
+				response, err = next.RoundTrip(attemptRequest)
+				if !isRetryable(request, response, err) || attempt >= maxRetries {
+					return response, err
+				}
+
+				// prepare the body of the next attempt before releasing this response, so the caller can still read it if the body can't be replayed
+				if request.Body != nil && request.Body != http.NoBody {
+					if request.GetBody == nil {
+						return response, err
+					}
+					body, bodyErr := request.GetBody()
+					if bodyErr != nil {
+						return response, err
+					}
+					attemptRequest = request.Clone(request.Context())
+					attemptRequest.Body = body
+				}
+
+				wait := getRetryDelay(response, backoff(attempt), time.Now())
+
+				// release the connection of the failed attempt before retrying
+				if response != nil {
+					response.Body.Close()
+				}
+
+				log.Debug().Msgf("Retrying '%v %v' in %v after attempt %v failed", request.Method, redactURL(request.URL), wait, attempt+1)
+				metrics.recordRetry()
+
+				select {
+				case <-request.Context().Done():
+					return nil, request.Context().Err()
+				case <-time.After(wait):
+				}
+			}
+		})
+	}
+}
+
+type readOnlyRequestKey struct{}
+
+// WithReadOnlyRequest marks the requests made with the context as read-only, so a POST like a graphql query is retried on server errors like a GET
+func WithReadOnlyRequest(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyRequestKey{}, true)
+}
+
+func isReadOnlyRequest(request *http.Request) bool {
+	readOnly, _ := request.Context().Value(readOnlyRequestKey{}).(bool)
+	return readOnly
+}
+
+// isRetryable returns true if the request can be sent again without the risk of applying it twice; a POST or PATCH that reached the server might have been processed, even if it failed, so those are only retried when they were rejected by the rate limit or never sent, unless they're marked read-only
+func isRetryable(request *http.Request, response *http.Response, err error) bool {
+	if !isIdempotent(request.Method) && !isReadOnlyRequest(request) {
+		if err != nil {
+			return isDialError(err)
+		}
+		return isRateLimited(response)
+	}
+	if err != nil {
+		return true
+	}
+	return response.StatusCode >= http.StatusInternalServerError || isRateLimited(response)
+}
+
+// isRateLimited returns true if the request was rejected by the primary or secondary rate limit, which Github signals with a 403 as well as a 429, see https://docs.github.com/en/rest/overview/rate-limits-for-the-rest-api
+func isRateLimited(response *http.Response) bool {
+	if response.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return response.StatusCode == http.StatusForbidden && (response.Header.Get("Retry-After") != "" || response.Header.Get("X-RateLimit-Remaining") == "0")
+}
+
+// getRetryDelay returns the wait asked for by the Retry-After header or, when the rate limit is used up, until it resets; otherwise it's the backoff
+func getRetryDelay(response *http.Response, backoff time.Duration, now time.Time) time.Duration {
+	if response == nil || !isRateLimited(response) {
+		return backoff
+	}
+
+	wait := backoff
+	if value := response.Header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			wait = time.Duration(seconds) * time.Second
+		} else if date, err := http.ParseTime(value); err == nil {
+			wait = date.Sub(now)
+		}
+	} else if response.Header.Get("X-RateLimit-Remaining") == "0" {
+		if resetUnix, err := strconv.ParseInt(response.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			wait = time.Unix(resetUnix, 0).Sub(now)
+		}
+	}
+
+	if wait < 0 {
+		return 0
+	}
+	if wait > maxRateLimitWait {
+		return maxRateLimitWait
+	}
+	return wait
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isDialError returns true if the connection couldn't be set up, so the request never left the client
+func isDialError(err error) bool {
+	var dnsError *net.DNSError
+	if errors.As(err, &dnsError) {
+		return true
+	}
+	var opError *net.OpError
+	return errors.As(err, &opError) && opError.Op == "dial"
+}
+
+// rateLimitMiddleware keeps track of the rate limit headers and holds requests back until the limit resets once it's used up, see https://developer.github.com/v3/#rate-limiting
+func rateLimitMiddleware(now func() time.Time) middleware {
+
+	var mutex sync.Mutex
+	var remaining = -1
+	var reset time.Time
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+
+			mutex.Lock()
+			wait := time.Duration(0)
+			if remaining == 0 && reset.After(now()) {
+				wait = reset.Sub(now())
+			}
+			mutex.Unlock()
+
+			if wait > 0 {
+				if wait > maxRateLimitWait {
+					wait = maxRateLimitWait
+				}
+				log.Warn().Msgf("Rate limit used up, waiting %v before sending '%v %v'", wait, request.Method, redactURL(request.URL))
+				select {
+				case <-request.Context().Done():
+					return nil, request.Context().Err()
+				case <-time.After(wait):
+				}
+			}
+
+			response, err := next.RoundTrip(request)
+			if err != nil {
+				return response, err
+			}
+
+			if value := response.Header.Get("X-RateLimit-Remaining"); value != "" {
+				if r, parseErr := strconv.Atoi(value); parseErr == nil {
+					mutex.Lock()
+					remaining = r
+					if resetUnix, parseErr := strconv.ParseInt(response.Header.Get("X-RateLimit-Reset"), 10, 64); parseErr == nil {
+						reset = time.Unix(resetUnix, 0)
+					}
+					mutex.Unlock()
+				}
+			}
+
+			return response, err
+		})
+	}
+}
+
+// loggingMiddleware logs every request with its status and duration, without secrets in the url
+func loggingMiddleware() middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			start := time.Now()
+			response, err := next.RoundTrip(request)
+			if err != nil {
+				log.Debug().Msgf("'%v %v' failed after %v: %v", request.Method, redactURL(request.URL), time.Since(start), redactSecrets(err.Error()))
+				return response, err
+			}
+			log.Debug().Msgf("'%v %v' returned status code %v in %v", request.Method, redactURL(request.URL), response.StatusCode, time.Since(start))
+			return response, err
+		})
+	}
+}
+
+// metricsMiddleware counts requests, their status codes and duration
+func metricsMiddleware(metrics *Metrics) middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			start := time.Now()
+			response, err := next.RoundTrip(request)
+			statusCode := 0
+			if response != nil {
+				statusCode = response.StatusCode
+			}
+			metrics.recordRequest(statusCode, time.Since(start))
+			return response, err
+		})
+	}
+}
+
+// Metrics are collected by the metrics middleware for the whole run
+type Metrics struct {
+	mutex       sync.Mutex
+	Requests    int
+	Retries     int
+	Failures    int
+	CacheHits   int
+	StatusCodes map[int]int
+	Duration    time.Duration
+}
+
+func (m *Metrics) recordRequest(statusCode int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.StatusCodes == nil {
+		m.StatusCodes = map[int]int{}
+	}
+	m.Requests++
+	m.Duration += duration
+	if statusCode == 0 {
+		m.Failures++
+		return
+	}
+	m.StatusCodes[statusCode]++
+}
+
+func (m *Metrics) recordRetry() {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.Retries++
+}
+
+func (m *Metrics) recordCacheHit() {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.CacheHits++
+}
+
+// Summary returns a single line with the request counts, duration and status codes to log at the end of a run
+func (m *Metrics) Summary() string {
+	if m == nil {
+		m = &Metrics{}
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return fmt.Sprintf("Made %v api request(s) in %v, of which %v retries, %v network failures and %v served from cache; status codes %v", m.Requests, m.Duration.Round(time.Millisecond), m.Retries, m.Failures, m.CacheHits, m.StatusCodes)
+}
+
+var (
+	// secretQueryParameters are removed from urls before they're logged
+	secretQueryParameters = []string{"access_token", "token", "client_secret", "private_token"}
+
+	// tokenRegex matches the formats of Github and Gitlab tokens and authorization header values
+	tokenRegex = regexp.MustCompile(`(?i)\b(gh[pousr]_[A-Za-z0-9]{16,}|github_pat_[A-Za-z0-9_]{16,}|glpat-[A-Za-z0-9_-]{16,}|(?:token|bearer|basic)\s+[A-Za-z0-9_.=+/-]{16,})`)
+
+	// tokenKeyRegex matches the values of json keys, form fields and parameters named like a token, secret or password
+	tokenKeyRegex = regexp.MustCompile(`(?i)((?:token|secret|password)"?\s*[:=]\s*"?)[^"&,\s]{8,}`)
+
+	// classicTokenRegex matches Github's classic tokens of 40 hex characters; commit shas look the same, so it's only applied to query parameters
+	classicTokenRegex = regexp.MustCompile(`(?i)\b[0-9a-f]{40}\b`)
+)
+
+// redactURL removes secrets from the query parameters of a url
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	redacted := *u
+	redacted.User = nil
+	query := redacted.Query()
+	for name, values := range query {
+		for i, v := range values {
+			values[i] = classicTokenRegex.ReplaceAllString(redactSecrets(v), "REDACTED")
+		}
+		query[name] = values
+	}
+	for _, p := range secretQueryParameters {
+		if query.Get(p) != "" {
+			query.Set(p, "REDACTED")
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redactTokens(redacted.String())
+}
+
+// redactSecrets replaces tokens recognizable by their prefix, authorization header values and the values of token-like keys; other hex strings are kept, since they're mostly commit shas
+func redactSecrets(value string) string {
+	return redactTokens(tokenKeyRegex.ReplaceAllString(value, "${1}REDACTED"))
+}
+
+// redactTokens replaces tokens recognizable by their prefix and authorization header values
+func redactTokens(value string) string {
+	return tokenRegex.ReplaceAllStringFunc(value, func(match string) string {
+		if i := strings.IndexAny(match, " \t"); i > 0 {
+			return match[:i] + " REDACTED"
+		}
+		return "REDACTED"
+	})
+}
@@ -0,0 +1,379 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/estafette/estafette-extension-github-release/pkg/releasenotes"
+	"github.com/rs/zerolog/log"
+)
+
+// APIClient allows to communicate with the Gitlab api, it implements the release.Provider interface
+type APIClient interface {
+	Name() string
+	ChangeRequestsName() string
+	GetMilestoneByVersion(ctx context.Context, repoOwner, repoName, version string, lookup github.MilestoneLookup) (milestone *releasenotes.Milestone, err error)
+	GetIssuesAndChangeRequestsForMilestone(ctx context.Context, repoOwner, repoName string, milestone releasenotes.Milestone) (issues []*releasenotes.Issue, changeRequests []*releasenotes.ChangeRequest, err error)
+	CreateRelease(ctx context.Context, repoOwner, repoName string, release releasenotes.Release) (createdRelease *releasenotes.Release, err error)
+	UploadReleaseAsset(ctx context.Context, repoOwner, repoName string, release releasenotes.Release, name, label, contentType string, content []byte) (uploadedAsset *releasenotes.ReleaseAsset, err error)
+	CloseMilestone(ctx context.Context, repoOwner, repoName string, milestone releasenotes.Milestone) (err error)
+}
+
+type apiClientImpl struct {
+	baseURL        string
+	accessToken    string
+	requestTimeout time.Duration
+	client         *http.Client
+}
+
+// NewAPIClient creates a client for the Gitlab api at the base url of the options, like https://gitlab.com/api/v4, sending requests through the middleware of the Github client
+func NewAPIClient(options github.Options) APIClient {
+	return &apiClientImpl{
+		baseURL:        strings.TrimSuffix(options.BaseURL, "/"),
+		accessToken:    options.AccessToken,
+		requestTimeout: options.RequestTimeout,
+		client:         github.NewProviderHTTPClient(options),
+	}
+}
+
+func (gl *apiClientImpl) Name() string {
+	return "gitlab"
+}
+
+func (gl *apiClientImpl) ChangeRequestsName() string {
+	return "merge requests"
+}
+
+func (gl *apiClientImpl) GetMilestoneByVersion(ctx context.Context, repoOwner, repoName, version string, lookup github.MilestoneLookup) (milestone *releasenotes.Milestone, err error) {
+
+	// https://docs.gitlab.com/ee/api/milestones.html#list-project-milestones
+	log.Info().Msgf("Retrieving milestone for version %v with lookup %v...", version, lookup.Describe())
+
+	query := "per_page=100"
+	if !lookup.IncludeClosed {
+		query += "&state=active"
+	}
+
+	pages, err := gl.callGitlabAPIPaginated(ctx, fmt.Sprintf("%v/projects/%v/milestones?%v", gl.baseURL, gl.projectID(repoOwner, repoName), query))
+	if err != nil {
+		return
+	}
+
+	titles := make([]string, 0)
+	milestones := make([]*releasenotes.Milestone, 0)
+	for _, page := range pages {
+		var pageMilestones []*Milestone
+		err = json.Unmarshal(page, &pageMilestones)
+		if err != nil {
+			return
+		}
+		for _, m := range pageMilestones {
+			titles = append(titles, m.Title)
+			milestones = append(milestones, m.toMilestone())
+		}
+	}
+
+	states := make([]string, 0, len(milestones))
+	for _, m := range milestones {
+		states = append(states, m.State)
+	}
+
+	index, err := github.MatchMilestoneTitle(titles, states, version, lookup)
+	if err != nil {
+		return nil, err
+	}
+	milestone = milestones[index]
+
+	log.Info().Msgf("Retrieved %v milestone %v", milestone.State, milestone.Title)
+
+	return milestone, nil
+}
+
+func (gl *apiClientImpl) GetIssuesAndChangeRequestsForMilestone(ctx context.Context, repoOwner, repoName string, milestone releasenotes.Milestone) (issues []*releasenotes.Issue, changeRequests []*releasenotes.ChangeRequest, err error) {
+
+	// https://docs.gitlab.com/ee/api/milestones.html#get-all-issues-assigned-to-a-single-milestone
+	log.Info().Msgf("Retrieving issues for milestone %v...", milestone.Title)
+
+	pages, err := gl.callGitlabAPIPaginated(ctx, fmt.Sprintf("%v/projects/%v/milestones/%v/issues?per_page=100", gl.baseURL, gl.projectID(repoOwner, repoName), milestone.ID))
+	if err != nil {
+		return
+	}
+
+	issues = make([]*releasenotes.Issue, 0)
+	for _, page := range pages {
+		var pageIssues []*Issue
+		err = json.Unmarshal(page, &pageIssues)
+		if err != nil {
+			return
+		}
+		for _, i := range pageIssues {
+			if i.State == "closed" {
+				issues = append(issues, i.toIssue())
+			}
+		}
+	}
+
+	// https://docs.gitlab.com/ee/api/milestones.html#get-all-merge-requests-assigned-to-a-single-milestone
+	pages, err = gl.callGitlabAPIPaginated(ctx, fmt.Sprintf("%v/projects/%v/milestones/%v/merge_requests?per_page=100", gl.baseURL, gl.projectID(repoOwner, repoName), milestone.ID))
+	if err != nil {
+		return
+	}
+
+	changeRequests = make([]*releasenotes.ChangeRequest, 0)
+	for _, page := range pages {
+		var pageMergeRequests []*MergeRequest
+		err = json.Unmarshal(page, &pageMergeRequests)
+		if err != nil {
+			return
+		}
+		for _, mr := range pageMergeRequests {
+			if mr.State == "merged" {
+				changeRequests = append(changeRequests, mr.toChangeRequest())
+			}
+		}
+	}
+
+	log.Info().Msgf("Retrieved %v issues and %v merge requests", len(issues), len(changeRequests))
+
+	return issues, changeRequests, nil
+}
+
+func (gl *apiClientImpl) CreateRelease(ctx context.Context, repoOwner, repoName string, release releasenotes.Release) (createdRelease *releasenotes.Release, err error) {
+
+	// https://docs.gitlab.com/ee/api/releases/#create-a-release
+	log.Info().Msgf("Creating release %v...", release.Name)
+
+	createRequest := ReleaseCreateRequest{
+		Name:        release.Name,
+		TagName:     release.TagName,
+		Description: release.Body,
+		Ref:         release.TargetCommitish,
+		Milestones:  release.Milestones,
+	}
+
+	body, err := gl.callGitlabAPI(ctx, "POST", fmt.Sprintf("%v/projects/%v/releases", gl.baseURL, gl.projectID(repoOwner, repoName)), "application/json", []int{http.StatusCreated, http.StatusConflict}, createRequest)
+	if err != nil {
+		return
+	}
+
+	var created Release
+	err = json.Unmarshal(body, &created)
+	if err != nil {
+		return
+	}
+	if created.TagName == "" {
+		log.Info().Msg("Release already exist, skipping")
+		return nil, nil
+	}
+
+	log.Info().Msg("Created release")
+
+	createdRelease = &release
+	createdRelease.WebURL = created.Links.Self
+
+	return createdRelease, nil
+}
+
+func (gl *apiClientImpl) UploadReleaseAsset(ctx context.Context, repoOwner, repoName string, release releasenotes.Release, name, label, contentType string, content []byte) (uploadedAsset *releasenotes.ReleaseAsset, err error) {
+
+	// https://docs.gitlab.com/ee/api/projects.html#upload-a-file
+	log.Info().Msgf("Uploading release asset %v...", name)
+
+	var requestBody bytes.Buffer
+	writer := multipart.NewWriter(&requestBody)
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		return
+	}
+	_, err = part.Write(content)
+	if err != nil {
+		return
+	}
+	err = writer.Close()
+	if err != nil {
+		return
+	}
+
+	body, _, err := github.CallProviderAPI(ctx, gl.client, gl.requestTimeout, "POST", fmt.Sprintf("%v/projects/%v/uploads", gl.baseURL, gl.projectID(repoOwner, repoName)), gl.headers(), writer.FormDataContentType(), []int{http.StatusCreated}, requestBody.Bytes())
+	if err != nil {
+		return
+	}
+
+	var upload Upload
+	err = json.Unmarshal(body, &upload)
+	if err != nil {
+		return
+	}
+
+	// the upload's url is relative to the project's web url, which the api base url doesn't tell
+	projectURL, err := gl.getProjectWebURL(ctx, repoOwner, repoName)
+	if err != nil {
+		return
+	}
+
+	// https://docs.gitlab.com/ee/api/releases/links.html#create-a-link
+	linkName := name
+	if label != "" {
+		linkName = label
+	}
+	link := ReleaseLink{
+		Name:     linkName,
+		URL:      projectURL + upload.URL,
+		LinkType: "package",
+	}
+
+	body, err = gl.callGitlabAPI(ctx, "POST", fmt.Sprintf("%v/projects/%v/releases/%v/assets/links", gl.baseURL, gl.projectID(repoOwner, repoName), url.PathEscape(release.TagName)), "application/json", []int{http.StatusCreated}, link)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(body, &link)
+	if err != nil {
+		return
+	}
+
+	log.Info().Msgf("Uploaded release asset %v", name)
+
+	return &releasenotes.ReleaseAsset{
+		Name:        name,
+		Label:       label,
+		ContentType: contentType,
+		Size:        len(content),
+		DownloadURL: link.URL,
+	}, nil
+}
+
+func (gl *apiClientImpl) CloseMilestone(ctx context.Context, repoOwner, repoName string, milestone releasenotes.Milestone) (err error) {
+
+	// https://docs.gitlab.com/ee/api/milestones.html#edit-milestone
+	log.Info().Msgf("Closing milestone %v...", milestone.Title)
+
+	_, err = gl.callGitlabAPI(ctx, "PUT", fmt.Sprintf("%v/projects/%v/milestones/%v", gl.baseURL, gl.projectID(repoOwner, repoName), milestone.ID), "application/json", []int{http.StatusOK}, MilestoneUpdateRequest{StateEvent: "close"})
+	if err != nil {
+		return
+	}
+
+	log.Info().Msg("Closed milestone")
+
+	return nil
+}
+
+// projectID returns the url encoded path of the project, which gitlab accepts instead of the numeric id
+func (gl *apiClientImpl) projectID(repoOwner, repoName string) string {
+	return url.PathEscape(fmt.Sprintf("%v/%v", repoOwner, repoName))
+}
+
+// getProjectWebURL returns the url of the project in the gitlab ui as reported by the api
+func (gl *apiClientImpl) getProjectWebURL(ctx context.Context, repoOwner, repoName string) (webURL string, err error) {
+
+	// https://docs.gitlab.com/ee/api/projects.html#get-single-project
+	body, err := gl.callGitlabAPI(ctx, "GET", fmt.Sprintf("%v/projects/%v", gl.baseURL, gl.projectID(repoOwner, repoName)), "", []int{http.StatusOK}, nil)
+	if err != nil {
+		return
+	}
+
+	var project Project
+	err = json.Unmarshal(body, &project)
+	if err != nil {
+		return
+	}
+
+	return strings.TrimSuffix(project.WebURL, "/"), nil
+}
+
+func (gl *apiClientImpl) headers() map[string]string {
+	return map[string]string{
+		"PRIVATE-TOKEN": gl.accessToken,
+	}
+}
+
+// callGitlabAPIPaginated retrieves all pages of a list by following the next links in the Link header
+func (gl *apiClientImpl) callGitlabAPIPaginated(ctx context.Context, url string) (pages [][]byte, err error) {
+
+	// https://docs.gitlab.com/ee/api/README.html#pagination-link-header
+	for url != "" {
+		body, header, err := github.CallProviderAPI(ctx, gl.client, gl.requestTimeout, "GET", url, gl.headers(), "", []int{http.StatusOK}, nil)
+		if err != nil {
+			return pages, err
+		}
+		pages = append(pages, body)
+		url = github.GetNextPageURL(header.Get("Link"))
+	}
+
+	return pages, nil
+}
+
+func (gl *apiClientImpl) callGitlabAPI(ctx context.Context, method, url, contentType string, validStatusCodes []int, params interface{}) (body []byte, err error) {
+
+	var requestBody []byte
+	if params != nil {
+		requestBody, err = json.Marshal(params)
+		if err != nil {
+			return
+		}
+	}
+
+	body, _, err = github.CallProviderAPI(ctx, gl.client, gl.requestTimeout, method, url, gl.headers(), contentType, validStatusCodes, requestBody)
+
+	return
+}
+
+func (milestone *Milestone) toMilestone() *releasenotes.Milestone {
+
+	// gitlab calls open milestones active
+	state := milestone.State
+	if state == "active" {
+		state = "open"
+	}
+
+	return &releasenotes.Milestone{
+		ID:          milestone.ID,
+		Title:       milestone.Title,
+		State:       state,
+		Description: milestone.Description,
+		WebURL:      milestone.WebURL,
+	}
+}
+
+func (issue *Issue) toIssue() *releasenotes.Issue {
+	return &releasenotes.Issue{
+		Number:    issue.IID,
+		Reference: fmt.Sprintf("#%v", issue.IID),
+		Title:     issue.Title,
+		State:     issue.State,
+		WebURL:    issue.WebURL,
+		Assignee:  issue.Assignee.toUser(),
+		Labels:    issue.Labels,
+	}
+}
+
+func (mergeRequest *MergeRequest) toChangeRequest() *releasenotes.ChangeRequest {
+	return &releasenotes.ChangeRequest{
+		Number:    mergeRequest.IID,
+		Reference: fmt.Sprintf("!%v", mergeRequest.IID),
+		Title:     mergeRequest.Title,
+		State:     mergeRequest.State,
+		WebURL:    mergeRequest.WebURL,
+		Merged:    mergeRequest.State == "merged",
+		Assignee:  mergeRequest.Assignee.toUser(),
+		Labels:    mergeRequest.Labels,
+	}
+}
+
+func (user *User) toUser() *releasenotes.User {
+	if user == nil {
+		return nil
+	}
+	return &releasenotes.User{
+		Login:  user.Username,
+		WebURL: user.WebURL,
+	}
+}
@@ -0,0 +1,161 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/estafette/estafette-extension-github-release/pkg/releasenotes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIClient(t *testing.T) {
+
+	t.Run("GetMilestoneByVersionMapsActiveMilestoneToOpen", func(t *testing.T) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+			assert.Equal(t, "/api/v4/projects/estafette%2Fapp/milestones", r.URL.EscapedPath())
+			assert.Equal(t, "active", r.URL.Query().Get("state"))
+			fmt.Fprint(w, `[{"id":31,"iid":3,"title":"1.2.0","state":"active","web_url":"https://gitlab.com/estafette/app/-/milestones/3"},{"id":32,"iid":4,"title":"1.3.0","state":"active"}]`)
+		}))
+		defer server.Close()
+
+		client := NewAPIClient(github.Options{BaseURL: server.URL + "/api/v4", AccessToken: "secret", RequestTimeout: time.Minute})
+
+		// act
+		milestone, err := client.GetMilestoneByVersion(context.Background(), "estafette", "app", "1.2.0", github.MilestoneLookup{Strategy: github.MilestoneLookupExact})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 31, milestone.ID)
+		assert.Equal(t, "open", milestone.State)
+	})
+
+	t.Run("GetMilestoneByVersionSucceedsWithoutRequestTimeout", func(t *testing.T) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"id":31,"iid":3,"title":"1.2.0","state":"active"}]`)
+		}))
+		defer server.Close()
+
+		client := NewAPIClient(github.Options{BaseURL: server.URL + "/api/v4", AccessToken: "secret", RequestTimeout: 0})
+
+		// act
+		milestone, err := client.GetMilestoneByVersion(context.Background(), "estafette", "app", "1.2.0", github.MilestoneLookup{Strategy: github.MilestoneLookupExact})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 31, milestone.ID)
+	})
+
+	t.Run("GetIssuesAndChangeRequestsForMilestoneReturnsClosedIssuesAndMergedMergeRequests", func(t *testing.T) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v4/projects/estafette/app/milestones/31/issues":
+				fmt.Fprint(w, `[{"iid":12,"title":"Fix crash","state":"closed","web_url":"https://gitlab.com/estafette/app/-/issues/12","assignee":{"username":"jorrit"}},{"iid":13,"title":"Not done","state":"opened"}]`)
+			case "/api/v4/projects/estafette/app/milestones/31/merge_requests":
+				fmt.Fprint(w, `[{"iid":7,"title":"Add retries","state":"merged","web_url":"https://gitlab.com/estafette/app/-/merge_requests/7"},{"iid":8,"title":"Abandoned","state":"closed"}]`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		client := NewAPIClient(github.Options{BaseURL: server.URL + "/api/v4", AccessToken: "secret", RequestTimeout: time.Minute})
+
+		// act
+		issues, mergeRequests, err := client.GetIssuesAndChangeRequestsForMilestone(context.Background(), "estafette", "app", releasenotes.Milestone{ID: 31, Title: "1.2.0"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(issues))
+		assert.Equal(t, "#12", issues[0].Reference)
+		assert.Equal(t, "jorrit", issues[0].Assignee.Login)
+		assert.Equal(t, 1, len(mergeRequests))
+		assert.Equal(t, "!7", mergeRequests[0].Reference)
+		assert.True(t, mergeRequests[0].Merged)
+	})
+
+	t.Run("UploadReleaseAssetUploadsFileAndLinksItToTheRelease", func(t *testing.T) {
+
+		var link ReleaseLink
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v4/projects/estafette/app/uploads":
+				file, header, err := r.FormFile("file")
+				assert.Nil(t, err)
+				content, _ := ioutil.ReadAll(file)
+				assert.Equal(t, "app.zip", header.Filename)
+				assert.Equal(t, "zip content", string(content))
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"url":"/uploads/abc/app.zip","full_path":"/estafette/app/uploads/abc/app.zip"}`)
+			case "/api/v4/projects/estafette/app":
+				fmt.Fprintf(w, `{"id":3,"web_url":"%v/gitlab/estafette/app"}`, "http://"+r.Host)
+			case "/api/v4/projects/estafette/app/releases/v1.2.0/assets/links":
+				body, _ := ioutil.ReadAll(r.Body)
+				_ = json.Unmarshal(body, &link)
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, string(body))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		client := NewAPIClient(github.Options{BaseURL: server.URL + "/api/v4", AccessToken: "secret", RequestTimeout: time.Minute})
+
+		// act
+		asset, err := client.UploadReleaseAsset(context.Background(), "estafette", "app", releasenotes.Release{TagName: "v1.2.0"}, "app.zip", "Linux binary", "application/zip", []byte("zip content"))
+
+		assert.Nil(t, err)
+		assert.Equal(t, "Linux binary", link.Name)
+		assert.Equal(t, server.URL+"/gitlab/estafette/app/uploads/abc/app.zip", link.URL)
+		assert.Equal(t, server.URL+"/gitlab/estafette/app/uploads/abc/app.zip", asset.DownloadURL)
+	})
+
+	t.Run("UploadReleaseAssetIsNotRetriedAfterServerError", func(t *testing.T) {
+
+		uploads := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			uploads++
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		metrics := &github.Metrics{}
+		client := NewAPIClient(github.Options{BaseURL: server.URL + "/api/v4", AccessToken: "secret", MaxRetries: 3, Metrics: metrics})
+
+		// act
+		_, err := client.UploadReleaseAsset(context.Background(), "estafette", "app", releasenotes.Release{TagName: "v1.2.0"}, "app.zip", "", "application/zip", []byte("zip content"))
+
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, uploads)
+		assert.Equal(t, 1, metrics.Requests)
+	})
+
+	t.Run("CloseMilestoneSendsCloseStateEvent", func(t *testing.T) {
+
+		var updateRequest MilestoneUpdateRequest
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "PUT", r.Method)
+			assert.Equal(t, "/api/v4/projects/estafette/app/milestones/31", r.URL.Path)
+			body, _ := ioutil.ReadAll(r.Body)
+			_ = json.Unmarshal(body, &updateRequest)
+			fmt.Fprint(w, `{}`)
+		}))
+		defer server.Close()
+
+		client := NewAPIClient(github.Options{BaseURL: server.URL + "/api/v4", AccessToken: "secret", RequestTimeout: time.Minute})
+
+		// act
+		err := client.CloseMilestone(context.Background(), "estafette", "app", releasenotes.Milestone{ID: 31, Title: "1.2.0"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "close", updateRequest.StateEvent)
+	})
+}
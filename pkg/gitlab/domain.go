@@ -0,0 +1,78 @@
+package gitlab
+
+type Milestone struct {
+	ID          int    `json:"id"`
+	IID         int    `json:"iid"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state"`
+	WebURL      string `json:"web_url"`
+}
+
+type MilestoneUpdateRequest struct {
+	StateEvent string `json:"state_event"`
+}
+
+type Issue struct {
+	ID       int      `json:"id"`
+	IID      int      `json:"iid"`
+	Title    string   `json:"title"`
+	State    string   `json:"state"`
+	WebURL   string   `json:"web_url"`
+	Assignee *User    `json:"assignee"`
+	Labels   []string `json:"labels"`
+}
+
+type MergeRequest struct {
+	ID       int      `json:"id"`
+	IID      int      `json:"iid"`
+	Title    string   `json:"title"`
+	State    string   `json:"state"`
+	WebURL   string   `json:"web_url"`
+	Assignee *User    `json:"assignee"`
+	Labels   []string `json:"labels"`
+}
+
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	WebURL   string `json:"web_url"`
+}
+
+type ReleaseCreateRequest struct {
+	Name        string   `json:"name"`
+	TagName     string   `json:"tag_name"`
+	Description string   `json:"description"`
+	Ref         string   `json:"ref"`
+	Milestones  []string `json:"milestones,omitempty"`
+}
+
+type Release struct {
+	Name        string       `json:"name"`
+	TagName     string       `json:"tag_name"`
+	Description string       `json:"description"`
+	Links       ReleaseLinks `json:"_links"`
+}
+
+type ReleaseLinks struct {
+	Self string `json:"self"`
+}
+
+type Project struct {
+	ID     int    `json:"id"`
+	WebURL string `json:"web_url"`
+}
+
+type Upload struct {
+	Alt      string `json:"alt"`
+	URL      string `json:"url"`
+	FullPath string `json:"full_path"`
+	Markdown string `json:"markdown"`
+}
+
+type ReleaseLink struct {
+	ID       int    `json:"id,omitempty"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	LinkType string `json:"link_type,omitempty"`
+}
@@ -0,0 +1,295 @@
+package release
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	archiveFormatZip   = "zip"
+	archiveFormatTarGz = "tar.gz"
+)
+
+// archiveOptions control how assets are packaged before uploading them to a release
+type archiveOptions struct {
+	Format string
+	// Reproducible makes archives byte-for-byte identical for identical input, by fixing timestamps, permissions, ownership and entry order
+	Reproducible bool
+	// ModTime is the timestamp applied to all entries when Reproducible is set
+	ModTime time.Time
+}
+
+func (o archiveOptions) extension() string {
+	if o.Format == archiveFormatTarGz {
+		return ".tar.gz"
+	}
+	return ".zip"
+}
+
+func (o archiveOptions) contentType() string {
+	if o.Format == archiveFormatTarGz {
+		return "application/gzip"
+	}
+	return "application/zip"
+}
+
+type archiveEntry struct {
+	path string
+	name string
+	info os.FileInfo
+	// linkTarget is the relative path a symlink points to, which is stored instead of the content of its target
+	linkTarget string
+}
+
+// createArchive packages a file or directory into an archive next to it and returns the archive's filename
+func createArchive(sourceFilename string, options archiveOptions) (targetFilename string, err error) {
+
+	targetFilename = strings.TrimSuffix(sourceFilename, string(filepath.Separator)) + options.extension()
+
+	entries, err := collectArchiveEntries(sourceFilename)
+	if err != nil {
+		return targetFilename, err
+	}
+
+	switch options.Format {
+	case "", archiveFormatZip:
+		err = writeZipArchive(targetFilename, entries, options)
+	case archiveFormatTarGz:
+		err = writeTarGzArchive(targetFilename, entries, options)
+	default:
+		err = fmt.Errorf("Archive format %v is not supported, use %v or %v", options.Format, archiveFormatZip, archiveFormatTarGz)
+	}
+
+	return targetFilename, err
+}
+
+// collectArchiveEntries returns the source file or all files, directories and symlinks within the source directory, sorted by name; symlinks are kept as links, so they have to point within the source directory
+func collectArchiveEntries(sourceFilename string) (entries []archiveEntry, err error) {
+
+	sourceFilename = filepath.Clean(sourceFilename)
+
+	// the source itself is followed if it's a symlink, so an asset can point to a versioned binary or directory
+	root, err := filepath.EvalSymlinks(sourceFilename)
+	if err != nil {
+		return nil, err
+	}
+
+	// filepath.Walk uses Lstat, so symlinks within the directory are returned as links instead of being followed
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		entry := archiveEntry{
+			path: path,
+			name: filepath.ToSlash(filepath.Join(filepath.Base(sourceFilename), relativePath)),
+			info: info,
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			entry.linkTarget, err = os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if !isWithinDirectory(root, filepath.Join(filepath.Dir(path), entry.linkTarget)) || filepath.IsAbs(entry.linkTarget) {
+				return fmt.Errorf("Symlink %v points to %v, which is outside of asset %v; replace it with a copy or a relative symlink within the asset", path, entry.linkTarget, sourceFilename)
+			}
+			entry.linkTarget = filepath.ToSlash(entry.linkTarget)
+		}
+
+		entries = append(entries, entry)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+
+	return entries, nil
+}
+
+// isWithinDirectory returns true if the path is the directory or below it
+func isWithinDirectory(directory, path string) bool {
+	relativePath, err := filepath.Rel(directory, path)
+	return err == nil && relativePath != ".." && !strings.HasPrefix(relativePath, ".."+string(filepath.Separator))
+}
+
+func writeZipArchive(targetFilename string, entries []archiveEntry, options archiveOptions) error {
+
+	newZipFile, err := os.Create(targetFilename)
+	if err != nil {
+		return err
+	}
+	defer newZipFile.Close()
+
+	zipWriter := zip.NewWriter(newZipFile)
+
+	for _, e := range entries {
+		if err = addEntryToZip(zipWriter, e, options); err != nil {
+			return err
+		}
+	}
+
+	return zipWriter.Close()
+}
+
+func addEntryToZip(zipWriter *zip.Writer, entry archiveEntry, options archiveOptions) error {
+
+	header, err := zip.FileInfoHeader(entry.info)
+	if err != nil {
+		return err
+	}
+	header.Name = entry.name
+
+	if options.Reproducible {
+		header.Modified = options.ModTime
+		header.SetMode(normalizeFileMode(entry.info.Mode()))
+	}
+
+	if entry.info.IsDir() {
+		header.Name += "/"
+		_, err = zipWriter.CreateHeader(header)
+		return err
+	}
+
+	// zip stores a symlink as an entry with the link mode and the target as content
+	if entry.linkTarget != "" {
+		writer, err := zipWriter.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		_, err = writer.Write([]byte(entry.linkTarget))
+		return err
+	}
+
+	// Change to deflate to gain better compression
+	// see http://golang.org/pkg/archive/zip/#pkg-constants
+	header.Method = zip.Deflate
+
+	writer, err := zipWriter.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	return copyFileContent(writer, entry.path)
+}
+
+func writeTarGzArchive(targetFilename string, entries []archiveEntry, options archiveOptions) error {
+
+	newTarGzFile, err := os.Create(targetFilename)
+	if err != nil {
+		return err
+	}
+	defer newTarGzFile.Close()
+
+	// the gzip header's name and modification time are left empty to keep the output deterministic
+	gzipWriter := gzip.NewWriter(newTarGzFile)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for _, e := range entries {
+		if err = addEntryToTar(tarWriter, e, options); err != nil {
+			return err
+		}
+	}
+
+	if err = tarWriter.Close(); err != nil {
+		return err
+	}
+
+	return gzipWriter.Close()
+}
+
+func addEntryToTar(tarWriter *tar.Writer, entry archiveEntry, options archiveOptions) error {
+
+	header, err := tar.FileInfoHeader(entry.info, entry.linkTarget)
+	if err != nil {
+		return err
+	}
+	header.Name = entry.name
+
+	if options.Reproducible {
+		header.ModTime = options.ModTime
+		header.AccessTime = time.Time{}
+		header.ChangeTime = time.Time{}
+		header.Mode = int64(normalizeFileMode(entry.info.Mode()).Perm())
+		header.Uid = 0
+		header.Gid = 0
+		header.Uname = ""
+		header.Gname = ""
+		header.Format = tar.FormatPAX
+	}
+
+	if entry.info.IsDir() {
+		header.Name += "/"
+		return tarWriter.WriteHeader(header)
+	}
+	if entry.linkTarget != "" {
+		return tarWriter.WriteHeader(header)
+	}
+
+	if err = tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+
+	return copyFileContent(tarWriter, entry.path)
+}
+
+func copyFileContent(writer io.Writer, filename string) error {
+
+	fileToArchive, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer fileToArchive.Close()
+
+	_, err = io.Copy(writer, fileToArchive)
+	return err
+}
+
+// normalizeFileMode drops host specific permissions, only keeping whether a file is executable
+func normalizeFileMode(mode os.FileMode) os.FileMode {
+	if mode.IsDir() {
+		return os.ModeDir | 0755
+	}
+	if mode&os.ModeSymlink != 0 {
+		return os.ModeSymlink | 0777
+	}
+	if mode&0111 != 0 {
+		return 0755
+	}
+	return 0644
+}
+
+// getSourceDateEpoch returns the time set in the SOURCE_DATE_EPOCH envvar, see https://reproducible-builds.org/specs/source-date-epoch/
+func getSourceDateEpoch() (sourceDateEpoch time.Time, ok bool, err error) {
+
+	value := os.Getenv("SOURCE_DATE_EPOCH")
+	if value == "" {
+		return sourceDateEpoch, false, nil
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return sourceDateEpoch, false, fmt.Errorf("SOURCE_DATE_EPOCH value %v is not a valid unix timestamp: %v", value, err)
+	}
+
+	return time.Unix(seconds, 0).UTC(), true, nil
+}
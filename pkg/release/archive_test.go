@@ -0,0 +1,239 @@
+package release
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateArchive(t *testing.T) {
+
+	modTime := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, format := range []string{archiveFormatZip, archiveFormatTarGz} {
+
+		t.Run("ReturnsIdenticalChecksumForReproducible"+format+"WhenFileTimestampChanges", func(t *testing.T) {
+
+			dir, err := ioutil.TempDir("", "archive")
+			assert.Nil(t, err)
+			defer os.RemoveAll(dir)
+
+			sourceFilename := filepath.Join(dir, "estafette-cloudflare-dns")
+			err = ioutil.WriteFile(sourceFilename, []byte("binary content"), 0700)
+			assert.Nil(t, err)
+
+			options := archiveOptions{Format: format, Reproducible: true, ModTime: modTime}
+
+			// act
+			firstChecksum := archiveChecksum(t, sourceFilename, options)
+			err = os.Chtimes(sourceFilename, time.Now(), time.Now().Add(time.Hour))
+			assert.Nil(t, err)
+			secondChecksum := archiveChecksum(t, sourceFilename, options)
+
+			assert.Equal(t, firstChecksum, secondChecksum)
+		})
+	}
+
+	t.Run("ReturnsTargetFilenameWithFormatExtension", func(t *testing.T) {
+
+		dir, err := ioutil.TempDir("", "archive")
+		assert.Nil(t, err)
+		defer os.RemoveAll(dir)
+
+		sourceFilename := filepath.Join(dir, "estafette-cloudflare-dns")
+		err = ioutil.WriteFile(sourceFilename, []byte("binary content"), 0644)
+		assert.Nil(t, err)
+
+		// act
+		targetFilename, err := createArchive(sourceFilename, archiveOptions{Format: archiveFormatTarGz})
+
+		assert.Nil(t, err)
+		assert.Equal(t, sourceFilename+".tar.gz", targetFilename)
+	})
+
+	t.Run("ReturnsErrorForUnsupportedFormat", func(t *testing.T) {
+
+		dir, err := ioutil.TempDir("", "archive")
+		assert.Nil(t, err)
+		defer os.RemoveAll(dir)
+
+		sourceFilename := filepath.Join(dir, "estafette-cloudflare-dns")
+		err = ioutil.WriteFile(sourceFilename, []byte("binary content"), 0644)
+		assert.Nil(t, err)
+
+		// act
+		_, err = createArchive(sourceFilename, archiveOptions{Format: "rar"})
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestCreateArchiveWithSymlinks(t *testing.T) {
+
+	t.Run("WritesSymlinkEntryToZip", func(t *testing.T) {
+
+		dir, err := ioutil.TempDir("", "archive")
+		assert.Nil(t, err)
+		defer os.RemoveAll(dir)
+
+		sourceDir := filepath.Join(dir, "dist")
+		assert.Nil(t, os.Mkdir(sourceDir, 0755))
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(sourceDir, "app-v1"), []byte("binary content"), 0755))
+		assert.Nil(t, os.Symlink("app-v1", filepath.Join(sourceDir, "app")))
+
+		// act
+		targetFilename, err := createArchive(sourceDir, archiveOptions{Format: archiveFormatZip, Reproducible: true})
+
+		assert.Nil(t, err)
+		reader, err := zip.OpenReader(targetFilename)
+		assert.Nil(t, err)
+		defer reader.Close()
+		found := false
+		for _, f := range reader.File {
+			if f.Name == "dist/app" {
+				found = true
+				assert.Equal(t, os.ModeSymlink|0777, f.Mode())
+				content, _ := f.Open()
+				target, _ := ioutil.ReadAll(content)
+				content.Close()
+				assert.Equal(t, "app-v1", string(target))
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("WritesSymlinkEntryToTarGz", func(t *testing.T) {
+
+		dir, err := ioutil.TempDir("", "archive")
+		assert.Nil(t, err)
+		defer os.RemoveAll(dir)
+
+		sourceDir := filepath.Join(dir, "dist")
+		assert.Nil(t, os.Mkdir(sourceDir, 0755))
+		assert.Nil(t, os.Symlink("app-v1", filepath.Join(sourceDir, "app")))
+
+		// act
+		targetFilename, err := createArchive(sourceDir, archiveOptions{Format: archiveFormatTarGz, Reproducible: true})
+
+		assert.Nil(t, err)
+		file, err := os.Open(targetFilename)
+		assert.Nil(t, err)
+		defer file.Close()
+		gzipReader, err := gzip.NewReader(file)
+		assert.Nil(t, err)
+		tarReader := tar.NewReader(gzipReader)
+		links := map[string]string{}
+		for header, err := tarReader.Next(); err == nil; header, err = tarReader.Next() {
+			if header.Typeflag == tar.TypeSymlink {
+				links[header.Name] = header.Linkname
+			}
+		}
+		assert.Equal(t, map[string]string{"dist/app": "app-v1"}, links)
+	})
+}
+
+func TestCollectArchiveEntries(t *testing.T) {
+
+	t.Run("ReturnsDirectoryEntriesSortedByName", func(t *testing.T) {
+
+		dir, err := ioutil.TempDir("", "archive")
+		assert.Nil(t, err)
+		defer os.RemoveAll(dir)
+
+		sourceDir := filepath.Join(dir, "dist")
+		assert.Nil(t, os.Mkdir(sourceDir, 0755))
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(sourceDir, "b"), []byte("b"), 0644))
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(sourceDir, "a"), []byte("a"), 0644))
+
+		// act
+		entries, err := collectArchiveEntries(sourceDir)
+
+		assert.Nil(t, err)
+		names := []string{}
+		for _, e := range entries {
+			names = append(names, e.name)
+		}
+		assert.Equal(t, []string{"dist", "dist/a", "dist/b"}, names)
+	})
+
+	t.Run("ReturnsErrorForSymlinkOutsideDirectory", func(t *testing.T) {
+
+		dir, err := ioutil.TempDir("", "archive")
+		assert.Nil(t, err)
+		defer os.RemoveAll(dir)
+
+		sourceDir := filepath.Join(dir, "dist")
+		assert.Nil(t, os.Mkdir(sourceDir, 0755))
+		assert.Nil(t, os.Symlink("../secrets", filepath.Join(sourceDir, "config")))
+
+		// act
+		_, err = collectArchiveEntries(sourceDir)
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("FollowsSourceSymlink", func(t *testing.T) {
+
+		dir, err := ioutil.TempDir("", "archive")
+		assert.Nil(t, err)
+		defer os.RemoveAll(dir)
+
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "app-v1"), []byte("binary content"), 0755))
+		assert.Nil(t, os.Symlink("app-v1", filepath.Join(dir, "app")))
+
+		// act
+		entries, err := collectArchiveEntries(filepath.Join(dir, "app"))
+
+		assert.Nil(t, err)
+		if assert.Equal(t, 1, len(entries)) {
+			assert.Equal(t, "app", entries[0].name)
+			assert.True(t, entries[0].info.Mode().IsRegular())
+		}
+	})
+}
+
+func TestNormalizeFileMode(t *testing.T) {
+
+	t.Run("ReturnsExecutableModeIfAnyExecuteBitIsSet", func(t *testing.T) {
+
+		// act
+		mode := normalizeFileMode(0700)
+
+		assert.Equal(t, os.FileMode(0755), mode)
+	})
+
+	t.Run("ReturnsLinkModeForSymlinks", func(t *testing.T) {
+
+		// act
+		mode := normalizeFileMode(os.ModeSymlink | 0755)
+
+		assert.Equal(t, os.ModeSymlink|0777, mode)
+	})
+
+	t.Run("ReturnsReadWriteModeIfNoExecuteBitIsSet", func(t *testing.T) {
+
+		// act
+		mode := normalizeFileMode(0600)
+
+		assert.Equal(t, os.FileMode(0644), mode)
+	})
+}
+
+func archiveChecksum(t *testing.T, sourceFilename string, options archiveOptions) [32]byte {
+
+	targetFilename, err := createArchive(sourceFilename, options)
+	assert.Nil(t, err)
+
+	content, err := ioutil.ReadFile(targetFilename)
+	assert.Nil(t, err)
+
+	return sha256.Sum256(content)
+}
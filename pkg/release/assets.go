@@ -0,0 +1,124 @@
+package release
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Asset is a file or directory to be archived and uploaded to the release; it can be configured as a plain path or as an object
+type Asset struct {
+	Path  string `json:"path" yaml:"path"`
+	Name  string `json:"name,omitempty" yaml:"name,omitempty"`
+	Label string `json:"label,omitempty" yaml:"label,omitempty"`
+	OS    string `json:"os,omitempty" yaml:"os,omitempty"`
+	Arch  string `json:"arch,omitempty" yaml:"arch,omitempty"`
+}
+
+// UnmarshalYAML accepts both a plain string with the path and an object
+func (a *Asset) UnmarshalYAML(unmarshal func(interface{}) error) error {
+
+	var path string
+	if err := unmarshal(&path); err == nil {
+		*a = Asset{Path: path}
+		return nil
+	}
+
+	// use an alias type to avoid recursing into this function
+	type assetAlias Asset
+	var alias assetAlias
+	if err := unmarshal(&alias); err != nil {
+		return err
+	}
+
+	*a = Asset(alias)
+	return nil
+}
+
+// UnmarshalJSON accepts both a plain string with the path and an object, like UnmarshalYAML, for programs passing the params as json
+func (a *Asset) UnmarshalJSON(data []byte) error {
+
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		*a = Asset{Path: path}
+		return nil
+	}
+
+	// use an alias type to avoid recursing into this function
+	type assetAlias Asset
+	var alias assetAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+
+	*a = Asset(alias)
+	return nil
+}
+
+// assetTemplateData holds the values available in the name and label templates of an asset
+type assetTemplateData struct {
+	Version string
+	OS      string
+	Arch    string
+	Repo    string
+}
+
+// releaseAsset is an asset with its name and label templates rendered
+type releaseAsset struct {
+	Path  string
+	Name  string
+	Label string
+}
+
+func (a Asset) render(version, repo string, options archiveOptions) (asset releaseAsset, err error) {
+
+	data := assetTemplateData{
+		Version: version,
+		OS:      a.OS,
+		Arch:    a.Arch,
+		Repo:    repo,
+	}
+
+	asset.Path = a.Path
+
+	asset.Name, err = renderAssetTemplate("name", a.Name, data)
+	if err != nil {
+		return asset, err
+	}
+	if asset.Name == "" {
+		asset.Name = filepath.Base(strings.TrimSuffix(a.Path, string(filepath.Separator)))
+	}
+	if !strings.HasSuffix(asset.Name, options.extension()) {
+		asset.Name += options.extension()
+	}
+
+	asset.Label, err = renderAssetTemplate("label", a.Label, data)
+	if err != nil {
+		return asset, err
+	}
+
+	return asset, nil
+}
+
+func renderAssetTemplate(field, text string, data assetTemplateData) (string, error) {
+
+	if text == "" {
+		return "", nil
+	}
+
+	tmpl, err := template.New(field).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("Parsing %v template '%v' failed: %v", field, text, err)
+	}
+
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, data)
+	if err != nil {
+		return "", fmt.Errorf("Rendering %v template '%v' failed: %v", field, text, err)
+	}
+
+	return buffer.String(), nil
+}
@@ -0,0 +1,133 @@
+package release
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestAssetUnmarshalYAML(t *testing.T) {
+
+	t.Run("UnmarshalsPlainStringAsPath", func(t *testing.T) {
+
+		var params Params
+
+		// act
+		err := yaml.Unmarshal([]byte("assets:\n- ./publish/estafette-cloudflare-dns\n"), &params)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []Asset{{Path: "./publish/estafette-cloudflare-dns"}}, params.Assets)
+	})
+
+	t.Run("UnmarshalsObjectWithNameAndLabel", func(t *testing.T) {
+
+		var params Params
+
+		// act
+		err := yaml.Unmarshal([]byte("assets:\n- path: ./publish/linux-amd64/estafette-cloudflare-dns\n  name: '{{.Repo}}-{{.OS}}-{{.Arch}}'\n  label: Linux x86_64 binary\n  os: linux\n  arch: amd64\n"), &params)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []Asset{{
+			Path:  "./publish/linux-amd64/estafette-cloudflare-dns",
+			Name:  "{{.Repo}}-{{.OS}}-{{.Arch}}",
+			Label: "Linux x86_64 binary",
+			OS:    "linux",
+			Arch:  "amd64",
+		}}, params.Assets)
+	})
+
+	t.Run("UnmarshalsMixOfStringsAndObjects", func(t *testing.T) {
+
+		var params Params
+
+		// act
+		err := yaml.Unmarshal([]byte("assets:\n- ./publish/a\n- path: ./publish/b\n"), &params)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(params.Assets))
+		assert.Equal(t, "./publish/a", params.Assets[0].Path)
+		assert.Equal(t, "./publish/b", params.Assets[1].Path)
+	})
+}
+
+func TestAssetUnmarshalJSON(t *testing.T) {
+
+	t.Run("UnmarshalsMixOfStringsAndObjects", func(t *testing.T) {
+
+		var params Params
+
+		// act
+		err := json.Unmarshal([]byte(`{"assets":["./publish/a",{"path":"./publish/b","label":"Linux x86_64 binary"}]}`), &params)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []Asset{{Path: "./publish/a"}, {Path: "./publish/b", Label: "Linux x86_64 binary"}}, params.Assets)
+	})
+
+	t.Run("ReturnsErrorForOtherTypes", func(t *testing.T) {
+
+		var params Params
+
+		// act
+		err := json.Unmarshal([]byte(`{"assets":[42]}`), &params)
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestAssetRender(t *testing.T) {
+
+	options := archiveOptions{Format: archiveFormatZip}
+
+	t.Run("DefaultsNameToBaseOfPathWithArchiveExtension", func(t *testing.T) {
+
+		asset := Asset{Path: "./publish/estafette-cloudflare-dns"}
+
+		// act
+		releaseAsset, err := asset.render("1.2.0", "estafette-cloudflare-dns", options)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "estafette-cloudflare-dns.zip", releaseAsset.Name)
+		assert.Equal(t, "", releaseAsset.Label)
+	})
+
+	t.Run("RendersNameAndLabelTemplates", func(t *testing.T) {
+
+		asset := Asset{
+			Path:  "./publish/linux-amd64/estafette-cloudflare-dns",
+			Name:  "{{.Repo}}-{{.Version}}-{{.OS}}-{{.Arch}}",
+			Label: "Linux x86_64 binary v{{.Version}}",
+			OS:    "linux",
+			Arch:  "amd64",
+		}
+
+		// act
+		releaseAsset, err := asset.render("1.2.0", "estafette-cloudflare-dns", options)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "estafette-cloudflare-dns-1.2.0-linux-amd64.zip", releaseAsset.Name)
+		assert.Equal(t, "Linux x86_64 binary v1.2.0", releaseAsset.Label)
+	})
+
+	t.Run("DoesNotAppendExtensionIfNameAlreadyHasIt", func(t *testing.T) {
+
+		asset := Asset{Path: "./publish/estafette-cloudflare-dns", Name: "{{.Repo}}.zip"}
+
+		// act
+		releaseAsset, err := asset.render("1.2.0", "estafette-cloudflare-dns", options)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "estafette-cloudflare-dns.zip", releaseAsset.Name)
+	})
+
+	t.Run("ReturnsErrorForInvalidTemplate", func(t *testing.T) {
+
+		asset := Asset{Path: "./publish/estafette-cloudflare-dns", Name: "{{.Unknown}}"}
+
+		// act
+		_, err := asset.render("1.2.0", "estafette-cloudflare-dns", options)
+
+		assert.NotNil(t, err)
+	})
+}
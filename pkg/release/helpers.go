@@ -0,0 +1,24 @@
+package release
+
+import (
+	"fmt"
+	"strings"
+)
+
+func capitalize(input string) string {
+	runes := []rune(input)
+	if len(runes) > 0 {
+		return strings.ToUpper(string(runes[0])) + string(runes[1:])
+	}
+
+	return input
+}
+
+func formatIssueNumbers(numbers []int) string {
+	formatted := make([]string, 0, len(numbers))
+	for _, n := range numbers {
+		formatted = append(formatted, fmt.Sprintf("#%v", n))
+	}
+
+	return strings.Join(formatted, ", ")
+}
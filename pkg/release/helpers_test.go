@@ -0,0 +1,61 @@
+package release
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapitalize(t *testing.T) {
+
+	t.Run("ReturnsEmptyStringForEmptyInput", func(t *testing.T) {
+
+		// act
+		output := capitalize("")
+
+		assert.Equal(t, "", output)
+	})
+
+	t.Run("ReturnsInputWithFirstCharacterOfFirstWordAsUppercase", func(t *testing.T) {
+
+		// act
+		output := capitalize("lowercase")
+
+		assert.Equal(t, "Lowercase", output)
+	})
+
+	t.Run("ReturnsInputWithFirstCharacterOfOtherWordsAsLowercase", func(t *testing.T) {
+
+		// act
+		output := capitalize("lowercase of more than one word")
+
+		assert.Equal(t, "Lowercase of more than one word", output)
+	})
+
+	t.Run("ReturnsInputWithFirstCharacterOfWordWithDashesAsUppercase", func(t *testing.T) {
+
+		// act
+		output := capitalize("estafette-cloudflare-dns")
+
+		assert.Equal(t, "Estafette-cloudflare-dns", output)
+	})
+
+	t.Run("ReturnsSingleCharacterInputAsUppercase", func(t *testing.T) {
+
+		// act
+		output := capitalize("e")
+
+		assert.Equal(t, "E", output)
+	})
+}
+
+func TestFormatIssueNumbers(t *testing.T) {
+
+	t.Run("ReturnsCommaSeparatedNumbersWithHash", func(t *testing.T) {
+
+		// act
+		output := formatIssueNumbers([]int{12, 13})
+
+		assert.Equal(t, "#12, #13", output)
+	})
+}
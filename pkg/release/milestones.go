@@ -0,0 +1,175 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	openIssuesPolicyFail = "fail"
+	openIssuesPolicyWarn = "warn"
+	openIssuesPolicyMove = "move"
+
+	versionBumpPatch = "patch"
+	versionBumpMinor = "minor"
+	versionBumpMajor = "major"
+)
+
+// bumpVersion increments the patch, minor or major number of a version, resetting the lower numbers and dropping any pre-release label
+func bumpVersion(version, bump string) (string, error) {
+
+	prefix := ""
+	if strings.HasPrefix(version, "v") || strings.HasPrefix(version, "V") {
+		prefix = version[:1]
+	}
+
+	normalizedVersion := github.NormalizeVersion(version)
+	if i := strings.IndexAny(normalizedVersion, "-+"); i >= 0 {
+		normalizedVersion = normalizedVersion[:i]
+	}
+
+	parts := strings.Split(normalizedVersion, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("Version %v is not a valid semantic version", version)
+	}
+	numbers := make([]int, 3)
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return "", fmt.Errorf("Version %v is not a valid semantic version", version)
+		}
+		numbers[i] = n
+	}
+
+	switch bump {
+	case versionBumpPatch:
+		numbers[2]++
+	case versionBumpMinor:
+		numbers[1]++
+		numbers[2] = 0
+	case versionBumpMajor:
+		numbers[0]++
+		numbers[1] = 0
+		numbers[2] = 0
+	default:
+		return "", fmt.Errorf("Version bump %v is not supported, use %v, %v or %v", bump, versionBumpPatch, versionBumpMinor, versionBumpMajor)
+	}
+
+	return fmt.Sprintf("%v%v.%v.%v", prefix, numbers[0], numbers[1], numbers[2]), nil
+}
+
+// findOrCreateMilestone returns the milestone with the title, in any state, or creates it if it doesn't exist yet
+func findOrCreateMilestone(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName string, createRequest github.MilestoneUpdateRequest, tracker *mutationTracker) (milestone *github.Milestone, err error) {
+
+	milestones, err := githubAPIClient.GetMilestones(ctx, repoOwner, repoName, "all")
+	if err != nil {
+		return
+	}
+
+	for _, m := range milestones {
+		if m.Title == createRequest.Title {
+			log.Info().Msgf("Milestone %v already exists", m.Title)
+			return m, nil
+		}
+	}
+
+	milestone, err = githubAPIClient.CreateMilestone(ctx, repoOwner, repoName, createRequest)
+	if err != nil {
+		return
+	}
+
+	createdMilestone := *milestone
+	tracker.record(fmt.Sprintf("Created milestone %v", createdMilestone.Title), func(ctx context.Context) error {
+		return githubAPIClient.DeleteMilestone(ctx, repoOwner, repoName, createdMilestone)
+	})
+
+	return milestone, nil
+}
+
+// getNextMilestoneCreateRequest derives the next milestone from the released milestone, according to the next milestone parameters
+func getNextMilestoneCreateRequest(params Params, milestone github.Milestone, now time.Time) (createRequest github.MilestoneUpdateRequest, err error) {
+
+	nextTitle, err := bumpVersion(milestone.Title, params.NextMilestoneBump)
+	if err != nil {
+		return
+	}
+
+	createRequest = github.MilestoneUpdateRequest{
+		Title: nextTitle,
+		State: "open",
+	}
+	if params.NextMilestoneCopyDescription {
+		createRequest.Description = milestone.Description
+	}
+	if params.NextMilestoneDueInDays > 0 {
+		createRequest.DueOn = now.AddDate(0, 0, params.NextMilestoneDueInDays).Format(time.RFC3339)
+	}
+
+	return createRequest, nil
+}
+
+// createNextMilestone creates the milestone following the released milestone, unless it already exists
+func createNextMilestone(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName string, params Params, milestone github.Milestone, tracker *mutationTracker) (nextMilestone *github.Milestone, err error) {
+
+	createRequest, err := getNextMilestoneCreateRequest(params, milestone, time.Now().UTC())
+	if err != nil {
+		return
+	}
+
+	return findOrCreateMilestone(ctx, githubAPIClient, repoOwner, repoName, createRequest, tracker)
+}
+
+// handleOpenIssues applies the open issues policy to a milestone that is about to be closed
+func handleOpenIssues(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName string, params Params, milestone github.Milestone, tracker *mutationTracker) (err error) {
+
+	openIssues, err := githubAPIClient.GetIssuesForMilestone(ctx, repoOwner, repoName, milestone, "open")
+	if err != nil {
+		return
+	}
+	if len(openIssues) == 0 {
+		return nil
+	}
+
+	switch params.OpenIssuesPolicy {
+	case openIssuesPolicyFail:
+		return fmt.Errorf("Milestone %v still has %v open issues and pull requests", milestone.Title, len(openIssues))
+
+	case openIssuesPolicyMove:
+		createRequest, err := getNextMilestoneCreateRequest(params, milestone, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+
+		nextMilestone, err := findOrCreateMilestone(ctx, githubAPIClient, repoOwner, repoName, createRequest, tracker)
+		if err != nil {
+			return err
+		}
+
+		log.Info().Msgf("Moving %v open issues and pull requests from milestone %v to %v...", len(openIssues), milestone.Title, nextMilestone.Title)
+		for _, i := range openIssues {
+			err = githubAPIClient.SetIssueMilestone(ctx, repoOwner, repoName, i.Number, &nextMilestone.Number)
+			if err != nil {
+				return err
+			}
+
+			issueNumber, milestoneNumber := i.Number, milestone.Number
+			tracker.record(fmt.Sprintf("Moved #%v from milestone %v to %v", issueNumber, milestone.Title, nextMilestone.Title), func(ctx context.Context) error {
+				return githubAPIClient.SetIssueMilestone(ctx, repoOwner, repoName, issueNumber, &milestoneNumber)
+			})
+		}
+		log.Info().Msgf("Moved %v open issues and pull requests to milestone %v", len(openIssues), nextMilestone.Title)
+
+	default:
+		for _, i := range openIssues {
+			log.Warn().Msgf("Milestone %v is closed while #%v %v is still open", milestone.Title, i.Number, i.Title)
+		}
+	}
+
+	return nil
+}
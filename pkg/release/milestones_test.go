@@ -0,0 +1,84 @@
+package release
+
+import (
+	"testing"
+	"time"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBumpVersion(t *testing.T) {
+
+	t.Run("IncrementsPatchForPatchBump", func(t *testing.T) {
+
+		// act
+		version, err := bumpVersion("1.2.0", versionBumpPatch)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "1.2.1", version)
+	})
+
+	t.Run("IncrementsMinorAndResetsPatchForMinorBump", func(t *testing.T) {
+
+		// act
+		version, err := bumpVersion("1.2.3", versionBumpMinor)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "1.3.0", version)
+	})
+
+	t.Run("IncrementsMajorAndResetsMinorAndPatchForMajorBump", func(t *testing.T) {
+
+		// act
+		version, err := bumpVersion("1.2.3", versionBumpMajor)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "2.0.0", version)
+	})
+
+	t.Run("KeepsLeadingVAndAddsMissingPatch", func(t *testing.T) {
+
+		// act
+		version, err := bumpVersion("v1.2", versionBumpMinor)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "v1.3.0", version)
+	})
+
+	t.Run("ReturnsErrorForNonSemanticVersion", func(t *testing.T) {
+
+		// act
+		_, err := bumpVersion("release-next", versionBumpMinor)
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestGetNextMilestoneCreateRequest(t *testing.T) {
+
+	milestone := github.Milestone{Title: "1.2.0", Description: "Helm chart support"}
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("ReturnsOpenMilestoneWithBumpedTitle", func(t *testing.T) {
+
+		params := Params{NextMilestoneBump: versionBumpMinor}
+
+		// act
+		createRequest, err := getNextMilestoneCreateRequest(params, milestone, now)
+
+		assert.Nil(t, err)
+		assert.Equal(t, github.MilestoneUpdateRequest{Title: "1.3.0", State: "open"}, createRequest)
+	})
+
+	t.Run("CopiesDescriptionAndSetsDueDateIfConfigured", func(t *testing.T) {
+
+		params := Params{NextMilestoneBump: versionBumpPatch, NextMilestoneCopyDescription: true, NextMilestoneDueInDays: 14}
+
+		// act
+		createRequest, err := getNextMilestoneCreateRequest(params, milestone, now)
+
+		assert.Nil(t, err)
+		assert.Equal(t, github.MilestoneUpdateRequest{Title: "1.2.1", State: "open", Description: "Helm chart support", DueOn: "2020-01-15T12:00:00Z"}, createRequest)
+	})
+}
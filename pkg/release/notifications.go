@@ -0,0 +1,427 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/smtp"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	notificationTypeSlack   = "slack"
+	notificationTypeTeams   = "teams"
+	notificationTypeWebhook = "webhook"
+	notificationTypeEmail   = "email"
+
+	// a notification sink that doesn't respond in time shouldn't hold up the release
+	notificationTimeout = 30 * time.Second
+)
+
+// NotificationParams configure a sink that gets notified about the outcome of the release
+type NotificationParams struct {
+	Type      string `json:"type,omitempty" yaml:"type,omitempty"`
+	OnSuccess *bool  `json:"onSuccess,omitempty" yaml:"onSuccess,omitempty"`
+	OnFailure bool   `json:"onFailure,omitempty" yaml:"onFailure,omitempty"`
+
+	// name of the injected credentials holding the url, secret and smtp password, so they don't end up in plaintext parameters
+	Credentials string `json:"credentials,omitempty" yaml:"credentials,omitempty"`
+
+	// slack, teams and webhook; set from the credentials
+	URL string `json:"-" yaml:"-"`
+	// webhook; set from the credentials
+	Secret string `json:"-" yaml:"-"`
+
+	// email
+	SMTPHost     string   `json:"smtpHost,omitempty" yaml:"smtpHost,omitempty"`
+	SMTPPort     int      `json:"smtpPort,omitempty" yaml:"smtpPort,omitempty"`
+	SMTPUsername string   `json:"smtpUsername,omitempty" yaml:"smtpUsername,omitempty"`
+	SMTPPassword string   `json:"-" yaml:"-"`
+	From         string   `json:"from,omitempty" yaml:"from,omitempty"`
+	To           []string `json:"to,omitempty" yaml:"to,omitempty"`
+}
+
+// releaseNotification is the outcome of a release as sent to notification sinks
+type releaseNotification struct {
+	Succeeded  bool            `json:"succeeded"`
+	Repository string          `json:"repository"`
+	Version    string          `json:"version"`
+	Release    *github.Release `json:"release,omitempty"`
+	Notes      string          `json:"notes,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	BuildURL   string          `json:"buildUrl,omitempty"`
+}
+
+func (n releaseNotification) title() string {
+	if !n.Succeeded {
+		return fmt.Sprintf("Releasing %v v%v failed", n.Repository, n.Version)
+	}
+	if n.Release != nil && n.Release.Name != "" {
+		return fmt.Sprintf("Released %v", n.Release.Name)
+	}
+	return fmt.Sprintf("Released %v v%v", n.Repository, n.Version)
+}
+
+func (n releaseNotification) url() string {
+	if n.Release != nil && n.Release.HTMLURL != "" {
+		return n.Release.HTMLURL
+	}
+	return n.BuildURL
+}
+
+func (n releaseNotification) text() string {
+	if !n.Succeeded {
+		return n.Error
+	}
+	return n.Notes
+}
+
+// notificationSink delivers release notifications to an external system
+type notificationSink interface {
+	Name() string
+	Notify(ctx context.Context, notification releaseNotification) error
+}
+
+func newNotificationSink(params NotificationParams, transport http.RoundTripper) (notificationSink, error) {
+	switch params.Type {
+	case notificationTypeSlack:
+		return &slackSink{url: params.URL, client: newNotificationHTTPClient(transport)}, nil
+	case notificationTypeTeams:
+		return &teamsSink{url: params.URL, client: newNotificationHTTPClient(transport)}, nil
+	case notificationTypeWebhook:
+		return &webhookSink{url: params.URL, secret: params.Secret, client: newNotificationHTTPClient(transport)}, nil
+	case notificationTypeEmail:
+		return &emailSink{host: params.SMTPHost, port: params.SMTPPort, username: params.SMTPUsername, password: params.SMTPPassword, from: params.From, to: params.To}, nil
+	}
+
+	return nil, fmt.Errorf("Notification type %v is not supported, use %v, %v, %v or %v", params.Type, notificationTypeSlack, notificationTypeTeams, notificationTypeWebhook, notificationTypeEmail)
+}
+
+// newNotificationHTTPClient posts notifications over the base transport of the run, so they use its proxy and tls settings; they aren't retried, because a sink that received a failed post might still have sent the message
+func newNotificationHTTPClient(transport http.RoundTripper) *http.Client {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   notificationTimeout,
+	}
+}
+
+func validateNotificationParams(params NotificationParams) error {
+	if _, err := newNotificationSink(params, nil); err != nil {
+		return err
+	}
+
+	switch params.Type {
+	case notificationTypeSlack, notificationTypeTeams, notificationTypeWebhook:
+		if params.URL == "" {
+			return fmt.Errorf("Notification of type %v needs credentials with a url", params.Type)
+		}
+	case notificationTypeEmail:
+		if params.SMTPHost == "" || params.From == "" || len(params.To) == 0 {
+			return fmt.Errorf("Notification of type %v needs smtpHost, from and to", params.Type)
+		}
+	}
+
+	return nil
+}
+
+// sendNotifications notifies all sinks configured for the outcome; failing sinks are logged, but don't fail the release
+func sendNotifications(ctx context.Context, transport http.RoundTripper, notifications []NotificationParams, notification releaseNotification) {
+
+	for _, n := range notifications {
+		if notification.Succeeded && n.OnSuccess != nil && !*n.OnSuccess {
+			continue
+		}
+		if !notification.Succeeded && !n.OnFailure {
+			continue
+		}
+
+		sink, err := newNotificationSink(n, transport)
+		if err != nil {
+			log.Warn().Err(err).Msg("Skipping notification")
+			continue
+		}
+
+		log.Info().Msgf("Sending %v notification...", sink.Name())
+		err = sink.Notify(ctx, notification)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Sending %v notification failed", sink.Name())
+			continue
+		}
+		log.Info().Msgf("Sent %v notification", sink.Name())
+	}
+}
+
+type slackSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *slackSink) Name() string {
+	return notificationTypeSlack
+}
+
+func (s *slackSink) Notify(ctx context.Context, notification releaseNotification) error {
+
+	// https://api.slack.com/messaging/webhooks
+	color := "good"
+	if !notification.Succeeded {
+		color = "danger"
+	}
+
+	payload := map[string]interface{}{
+		"attachments": []map[string]interface{}{
+			{
+				"fallback":   notification.title(),
+				"color":      color,
+				"title":      notification.title(),
+				"title_link": notification.url(),
+				"text":       formatSlackMarkdown(notification.text()),
+				"mrkdwn_in":  []string{"text"},
+			},
+		},
+	}
+
+	_, err := postJSON(ctx, s.client, s.url, payload, nil)
+	return err
+}
+
+var markdownLinkRegex = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+
+// formatSlackMarkdown converts the markdown of the release notes to slack's mrkdwn format
+func formatSlackMarkdown(markdown string) string {
+	markdown = markdownLinkRegex.ReplaceAllString(markdown, "<$2|$1>")
+	markdown = strings.Replace(markdown, "**", "*", -1)
+	return markdown
+}
+
+type teamsSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *teamsSink) Name() string {
+	return notificationTypeTeams
+}
+
+func (s *teamsSink) Notify(ctx context.Context, notification releaseNotification) error {
+
+	// https://docs.microsoft.com/en-us/outlook/actionable-messages/message-card-reference
+	themeColor := "2EB67D"
+	if !notification.Succeeded {
+		themeColor = "E01E5A"
+	}
+
+	card := map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    notification.title(),
+		"themeColor": themeColor,
+		"title":      notification.title(),
+		"text":       notification.text(),
+	}
+	if notification.url() != "" {
+		card["potentialAction"] = []map[string]interface{}{
+			{
+				"@type": "OpenUri",
+				"name":  "View release",
+				"targets": []map[string]string{
+					{"os": "default", "uri": notification.url()},
+				},
+			},
+		}
+	}
+
+	_, err := postJSON(ctx, s.client, s.url, card, nil)
+	return err
+}
+
+type webhookSink struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func (s *webhookSink) Name() string {
+	return notificationTypeWebhook
+}
+
+func (s *webhookSink) Notify(ctx context.Context, notification releaseNotification) error {
+	_, err := postJSON(ctx, s.client, s.url, notification, func(body []byte) map[string]string {
+		if s.secret == "" {
+			return nil
+		}
+		return map[string]string{"X-Estafette-Signature-256": "sha256=" + signPayload(s.secret, body)}
+	})
+	return err
+}
+
+// signPayload returns the hex encoded hmac-sha256 of the payload, so receivers can verify the webhook
+func signPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+type emailSink struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+func (s *emailSink) Name() string {
+	return notificationTypeEmail
+}
+
+func (s *emailSink) Notify(ctx context.Context, notification releaseNotification) error {
+
+	port := s.port
+	if port == 0 {
+		port = 587
+	}
+	address := net.JoinHostPort(s.host, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+
+	return sendMail(ctx, address, s.host, auth, s.from, s.to, formatEmail(s.from, s.to, notification))
+}
+
+// sendMail works like smtp.SendMail, but dials with the context and sets a deadline on the connection, so an unresponsive smtp server can't block the release
+func sendMail(ctx context.Context, address, host string, auth smtp.Auth, from string, to []string, message []byte) error {
+
+	dialer := &net.Dialer{Timeout: notificationTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(notificationTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err = client.Auth(auth); err != nil {
+				return err
+			}
+		}
+	}
+	if err = client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err = client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(message); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func formatEmail(from string, to []string, notification releaseNotification) []byte {
+
+	body := notification.text()
+	if notification.url() != "" {
+		body += "\r\n\r\n" + notification.url()
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("From: %v\r\n", from))
+	buffer.WriteString(fmt.Sprintf("To: %v\r\n", strings.Join(to, ", ")))
+	buffer.WriteString(fmt.Sprintf("Subject: %v\r\n", notification.title()))
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	buffer.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	buffer.WriteString("\r\n")
+	buffer.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+	buffer.WriteString("\r\n")
+
+	return buffer.Bytes()
+}
+
+// postJSON posts the payload as json, with optional extra headers computed from the serialized body
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}, headers func(body []byte) map[string]string) (responseBody []byte, err error) {
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+
+	request.Header.Add("Content-Type", "application/json")
+	if headers != nil {
+		for k, v := range headers(data) {
+			request.Header.Add(k, v)
+		}
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return
+	}
+	defer response.Body.Close()
+
+	responseBody, err = ioutil.ReadAll(response.Body)
+	if err != nil {
+		return
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		// the url is left out, because webhook urls contain secrets
+		return responseBody, fmt.Errorf("Status code %v for notification request is not successful. Body: %v", response.StatusCode, string(responseBody))
+	}
+
+	return responseBody, nil
+}
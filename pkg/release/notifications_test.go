@@ -0,0 +1,204 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookSinkNotify(t *testing.T) {
+
+	t.Run("PostsNotificationSignedWithSecret", func(t *testing.T) {
+
+		var receivedBody []byte
+		var receivedSignature string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			receivedBody, _ = ioutil.ReadAll(r.Body)
+			receivedSignature = r.Header.Get("X-Estafette-Signature-256")
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		sink := &webhookSink{url: server.URL, secret: "my-secret", client: newNotificationHTTPClient(nil)}
+
+		// act
+		err := sink.Notify(context.Background(), releaseNotification{Succeeded: true, Repository: "estafette/estafette-cloudflare-dns", Version: "1.2.0"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "sha256="+signPayload("my-secret", receivedBody), receivedSignature)
+
+		var notification releaseNotification
+		assert.Nil(t, json.Unmarshal(receivedBody, &notification))
+		assert.Equal(t, "1.2.0", notification.Version)
+	})
+
+	t.Run("ReturnsErrorForUnsuccessfulStatusCode", func(t *testing.T) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		sink := &webhookSink{url: server.URL, client: newNotificationHTTPClient(nil)}
+
+		// act
+		err := sink.Notify(context.Background(), releaseNotification{Succeeded: true})
+
+		assert.NotNil(t, err)
+		assert.NotContains(t, err.Error(), server.URL)
+	})
+
+	t.Run("DoesNotRetryAfterServerError", func(t *testing.T) {
+
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		sink := &webhookSink{url: server.URL, client: newNotificationHTTPClient(nil)}
+
+		// act
+		err := sink.Notify(context.Background(), releaseNotification{Succeeded: true})
+
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("ReturnsErrorWhenContextIsCancelled", func(t *testing.T) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		sink := &webhookSink{url: server.URL, client: newNotificationHTTPClient(nil)}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// act
+		err := sink.Notify(ctx, releaseNotification{Succeeded: true})
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestEmailSinkNotify(t *testing.T) {
+
+	t.Run("ReturnsErrorWhenSMTPServerDoesNotRespondBeforeDeadline", func(t *testing.T) {
+
+		// accept connections, but never send the smtp greeting
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer listener.Close()
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+			}
+		}()
+
+		host, port, _ := net.SplitHostPort(listener.Addr().String())
+		portNumber, _ := strconv.Atoi(port)
+		sink := &emailSink{host: host, port: portNumber, from: "ci@example.com", to: []string{"team@example.com"}}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+
+		// act
+		err = sink.Notify(ctx, releaseNotification{Succeeded: true})
+
+		assert.NotNil(t, err)
+		assert.True(t, time.Since(start) < notificationTimeout)
+	})
+}
+
+// countingTransport counts the requests it sends with the default transport
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func TestSendNotifications(t *testing.T) {
+
+	t.Run("OnlyNotifiesSinksWithOnFailureAfterFailure", func(t *testing.T) {
+
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+		}))
+		defer server.Close()
+
+		notifications := []NotificationParams{
+			{Type: notificationTypeWebhook, URL: server.URL},
+			{Type: notificationTypeSlack, URL: server.URL, OnFailure: true},
+		}
+
+		// act
+		sendNotifications(context.Background(), nil, notifications, releaseNotification{Succeeded: false, Error: "Creating release failed"})
+
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("SendsNotificationsThroughTransportOfTheRun", func(t *testing.T) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		transport := &countingTransport{}
+
+		// act
+		sendNotifications(context.Background(), transport, []NotificationParams{{Type: notificationTypeWebhook, URL: server.URL}}, releaseNotification{Succeeded: true})
+
+		assert.Equal(t, 1, transport.requests)
+	})
+}
+
+func TestFormatSlackMarkdown(t *testing.T) {
+
+	t.Run("ConvertsLinksAndBold", func(t *testing.T) {
+
+		// act
+		output := formatSlackMarkdown("**Resolved issues (1)**\n* Add official helm chart. [#12](https://github.com/estafette/estafette-cloudflare-dns/issues/12)\n")
+
+		assert.Equal(t, "*Resolved issues (1)*\n* Add official helm chart. <https://github.com/estafette/estafette-cloudflare-dns/issues/12|#12>\n", output)
+	})
+}
+
+func TestValidateNotificationParams(t *testing.T) {
+
+	t.Run("ReturnsErrorForUnsupportedType", func(t *testing.T) {
+
+		// act
+		err := validateNotificationParams(NotificationParams{Type: "pager"})
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReturnsErrorForEmailWithoutRecipients", func(t *testing.T) {
+
+		// act
+		err := validateNotificationParams(NotificationParams{Type: notificationTypeEmail, SMTPHost: "smtp.example.com", From: "ci@example.com"})
+
+		assert.NotNil(t, err)
+	})
+}
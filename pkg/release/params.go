@@ -0,0 +1,120 @@
+package release
+
+import (
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+)
+
+// Params are the parameters passed to this extension via the custom properties of the estafette stage
+type Params struct {
+	ReleaseVersion               string               `json:"version,omitempty" yaml:"version,omitempty"`
+	CloseMilestone               *bool                `json:"closeMilestone,omitempty" yaml:"closeMilestone,omitempty"`
+	ReleaseTitle                 string               `json:"title,omitempty" yaml:"title,omitempty"`
+	Draft                        bool                 `json:"draft,omitempty" yaml:"draft,omitempty"`
+	PreRelease                   bool                 `json:"prerelease,omitempty" yaml:"prerelease,omitempty"`
+	IgnoreMissingMilestone       bool                 `json:"ignoreMissingMilestone,omitempty" yaml:"ignoreMissingMilestone,omitempty"`
+	Assets                       []Asset              `json:"assets,omitempty" yaml:"assets,omitempty"`
+	Provenance                   bool                 `json:"provenance,omitempty" yaml:"provenance,omitempty"`
+	ArchiveFormat                string               `json:"archiveFormat,omitempty" yaml:"archiveFormat,omitempty"`
+	Reproducible                 bool                 `json:"reproducible,omitempty" yaml:"reproducible,omitempty"`
+	RollbackOnFailure            bool                 `json:"rollbackOnFailure,omitempty" yaml:"rollbackOnFailure,omitempty"`
+	MilestoneLookup              string               `json:"milestoneLookup,omitempty" yaml:"milestoneLookup,omitempty"`
+	MilestonePattern             string               `json:"milestonePattern,omitempty" yaml:"milestonePattern,omitempty"`
+	IncludeClosedMilestones      bool                 `json:"includeClosedMilestones,omitempty" yaml:"includeClosedMilestones,omitempty"`
+	OpenIssuesPolicy             string               `json:"openIssuesPolicy,omitempty" yaml:"openIssuesPolicy,omitempty"`
+	NextMilestoneBump            string               `json:"nextMilestoneBump,omitempty" yaml:"nextMilestoneBump,omitempty"`
+	CreateNextMilestone          bool                 `json:"createNextMilestone,omitempty" yaml:"createNextMilestone,omitempty"`
+	NextMilestoneDueInDays       int                  `json:"nextMilestoneDueInDays,omitempty" yaml:"nextMilestoneDueInDays,omitempty"`
+	NextMilestoneCopyDescription bool                 `json:"nextMilestoneCopyDescription,omitempty" yaml:"nextMilestoneCopyDescription,omitempty"`
+	MissingMilestonePolicy       string               `json:"missingMilestonePolicy,omitempty" yaml:"missingMilestonePolicy,omitempty"`
+	SyncMergedPullRequests       bool                 `json:"syncMergedPullRequests,omitempty" yaml:"syncMergedPullRequests,omitempty"`
+	ReleasedLabel                string               `json:"releasedLabel,omitempty" yaml:"releasedLabel,omitempty"`
+	ReleaseComment               bool                 `json:"releaseComment,omitempty" yaml:"releaseComment,omitempty"`
+	ReleaseCommentTemplate       string               `json:"releaseCommentTemplate,omitempty" yaml:"releaseCommentTemplate,omitempty"`
+	RequireGreenBuild            bool                 `json:"requireGreenBuild,omitempty" yaml:"requireGreenBuild,omitempty"`
+	IgnoreStatusContexts         []string             `json:"ignoreStatusContexts,omitempty" yaml:"ignoreStatusContexts,omitempty"`
+	RequireReachableFromBranch   bool                 `json:"requireReachableFromBranch,omitempty" yaml:"requireReachableFromBranch,omitempty"`
+	ReleaseBranch                string               `json:"releaseBranch,omitempty" yaml:"releaseBranch,omitempty"`
+	RequireNoOpenIssues          bool                 `json:"requireNoOpenIssues,omitempty" yaml:"requireNoOpenIssues,omitempty"`
+	Force                        bool                 `json:"force,omitempty" yaml:"force,omitempty"`
+	AllowRetag                   bool                 `json:"allowRetag,omitempty" yaml:"allowRetag,omitempty"`
+	AnnotatedTag                 bool                 `json:"annotatedTag,omitempty" yaml:"annotatedTag,omitempty"`
+	TaggerName                   string               `json:"taggerName,omitempty" yaml:"taggerName,omitempty"`
+	TaggerEmail                  string               `json:"taggerEmail,omitempty" yaml:"taggerEmail,omitempty"`
+	Notifications                []NotificationParams `json:"notifications,omitempty" yaml:"notifications,omitempty"`
+	Provider                     string               `json:"provider,omitempty" yaml:"provider,omitempty"`
+	APIBaseURL                   string               `json:"apiBaseUrl,omitempty" yaml:"apiBaseUrl,omitempty"`
+	UseGraphQL                   bool                 `json:"useGraphQL,omitempty" yaml:"useGraphQL,omitempty"`
+	TimeoutSeconds               int                  `json:"timeoutSeconds,omitempty" yaml:"timeoutSeconds,omitempty"`
+	RequestTimeoutSeconds        int                  `json:"requestTimeoutSeconds,omitempty" yaml:"requestTimeoutSeconds,omitempty"`
+	CacheDirectory               string               `json:"cacheDirectory,omitempty" yaml:"cacheDirectory,omitempty"`
+	CABundlePath                 string               `json:"caBundlePath,omitempty" yaml:"caBundlePath,omitempty"`
+	ClientCertificatePath        string               `json:"clientCertificatePath,omitempty" yaml:"clientCertificatePath,omitempty"`
+	ClientKeyPath                string               `json:"clientKeyPath,omitempty" yaml:"clientKeyPath,omitempty"`
+	Debug                        bool                 `json:"debug,omitempty" yaml:"debug,omitempty"`
+	HARFile                      string               `json:"harFile,omitempty" yaml:"harFile,omitempty"`
+}
+
+// SetDefaults fills in empty fields with convention-based defaults
+func (p *Params) SetDefaults(buildVersion, gitRepoName string) {
+
+	if p.ReleaseVersion == "" {
+		p.ReleaseVersion = buildVersion
+	}
+
+	if p.ReleaseTitle == "" {
+		p.ReleaseTitle = capitalize(gitRepoName)
+	}
+
+	if p.MissingMilestonePolicy == "" {
+		if p.IgnoreMissingMilestone {
+			p.MissingMilestonePolicy = missingMilestonePolicyIgnore
+		} else {
+			p.MissingMilestonePolicy = missingMilestonePolicyFail
+		}
+	}
+
+	if p.MilestoneLookup == "" {
+		p.MilestoneLookup = github.MilestoneLookupExact
+	}
+
+	if p.OpenIssuesPolicy == "" {
+		p.OpenIssuesPolicy = openIssuesPolicyWarn
+	}
+
+	if p.NextMilestoneBump == "" {
+		p.NextMilestoneBump = versionBumpMinor
+	}
+
+	if p.ReleaseCommentTemplate == "" {
+		p.ReleaseCommentTemplate = defaultReleaseCommentTemplate
+	}
+
+	if p.IgnoreStatusContexts == nil {
+		p.IgnoreStatusContexts = []string{"estafette"}
+	}
+
+	if p.ArchiveFormat == "" {
+		p.ArchiveFormat = archiveFormatZip
+	}
+
+	if p.TimeoutSeconds == 0 {
+		p.TimeoutSeconds = 1800
+	}
+
+	if p.RequestTimeoutSeconds == 0 {
+		p.RequestTimeoutSeconds = 300
+	}
+
+	if p.CloseMilestone == nil {
+		trueValue := true
+		p.CloseMilestone = &trueValue
+	}
+}
+
+func (p *Params) milestoneLookup() github.MilestoneLookup {
+	return github.MilestoneLookup{
+		Strategy:      p.MilestoneLookup,
+		Pattern:       p.MilestonePattern,
+		IncludeClosed: p.IncludeClosedMilestones,
+	}
+}
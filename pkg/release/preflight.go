@@ -0,0 +1,259 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// github rejects release assets of 2 GiB or larger, see https://help.github.com/en/github/administering-a-repository/about-releases#storage-and-bandwidth-quotas
+	maxReleaseAssetSize int64 = 2 * 1024 * 1024 * 1024
+)
+
+// requiredTokenScopes lists the oauth scopes of which the token needs at least one to create releases and update milestones
+var requiredTokenScopes = []string{"repo", "public_repo"}
+
+// PreflightError collects all problems found during preflight, so they can be fixed in one go
+type PreflightError struct {
+	problems []string
+}
+
+func (e *PreflightError) Error() string {
+	return fmt.Sprintf("Preflight found %v problem(s):\n- %v", len(e.problems), strings.Join(e.problems, "\n- "))
+}
+
+// runPreflight validates everything that can be validated before making any changes in Github and returns the rendered assets to upload
+func runPreflight(ctx context.Context, githubAPIClient github.APIClient, repoName string, params Params, milestone *github.Milestone, milestoneErr error, options archiveOptions) (releaseAssets []releaseAsset, err error) {
+
+	log.Info().Msg("Running preflight checks...")
+
+	problems := make([]string, 0)
+
+	problems = append(problems, checkMilestone(params, milestone, milestoneErr)...)
+	problems = append(problems, checkOpenIssuesPolicy(params, milestone)...)
+	problems = append(problems, checkNextMilestone(params, milestone)...)
+	problems = append(problems, checkArchiveFormat(options)...)
+	problems = append(problems, checkTimeouts(params)...)
+	problems = append(problems, checkDebug(params)...)
+	problems = append(problems, checkReleaseCommentTemplate(params)...)
+	problems = append(problems, checkNotifications(params)...)
+
+	releaseAssets, templateProblems := checkAssetTemplates(params, repoName, options)
+	problems = append(problems, templateProblems...)
+	problems = append(problems, checkAssetFiles(params.Assets)...)
+	problems = append(problems, checkTokenScopes(ctx, githubAPIClient)...)
+
+	if len(problems) > 0 {
+		return releaseAssets, &PreflightError{problems: problems}
+	}
+
+	log.Info().Msg("Preflight checks passed")
+
+	return releaseAssets, nil
+}
+
+func checkMilestone(params Params, milestone *github.Milestone, milestoneErr error) (problems []string) {
+
+	switch params.MissingMilestonePolicy {
+	case "", missingMilestonePolicyFail, missingMilestonePolicyIgnore, missingMilestonePolicyCreate:
+	default:
+		problems = append(problems, fmt.Sprintf("Missing milestone policy %v is not supported, use %v, %v or %v", params.MissingMilestonePolicy, missingMilestonePolicyFail, missingMilestonePolicyIgnore, missingMilestonePolicyCreate))
+	}
+
+	// an invalid lookup or a failure other than not finding the milestone can't be ignored nor resolved by creating the milestone
+	if err := params.milestoneLookup().Validate(); err != nil {
+		return append(problems, err.Error())
+	}
+	if milestoneErr != nil && !github.IsMilestoneNotFoundError(milestoneErr) {
+		return append(problems, fmt.Sprintf("Retrieving milestone failed: %v", milestoneErr))
+	}
+
+	if milestone != nil || params.MissingMilestonePolicy == missingMilestonePolicyIgnore || params.MissingMilestonePolicy == missingMilestonePolicyCreate {
+		return
+	}
+
+	if milestoneErr != nil {
+		return append(problems, fmt.Sprintf("%v, please create a milestone with title %v or set missingMilestonePolicy to create", milestoneErr, params.ReleaseVersion))
+	}
+
+	return append(problems, fmt.Sprintf("Milestone does not exist, please create a milestone with title %v and retry", params.ReleaseVersion))
+}
+
+func checkOpenIssuesPolicy(params Params, milestone *github.Milestone) (problems []string) {
+
+	switch params.OpenIssuesPolicy {
+	case openIssuesPolicyFail:
+		if milestone != nil && milestone.State != "closed" && params.CloseMilestone != nil && *params.CloseMilestone && milestone.OpenIssues > 0 {
+			problems = append(problems, fmt.Sprintf("Milestone %v still has %v open issues and pull requests", milestone.Title, milestone.OpenIssues))
+		}
+	case openIssuesPolicyMove:
+		if milestone != nil {
+			if _, err := bumpVersion(milestone.Title, params.NextMilestoneBump); err != nil {
+				problems = append(problems, fmt.Sprintf("Open issues cannot be moved to the next milestone: %v", err))
+			}
+		}
+	case openIssuesPolicyWarn:
+	default:
+		problems = append(problems, fmt.Sprintf("Open issues policy %v is not supported, use %v, %v or %v", params.OpenIssuesPolicy, openIssuesPolicyFail, openIssuesPolicyWarn, openIssuesPolicyMove))
+	}
+
+	return
+}
+
+func checkNextMilestone(params Params, milestone *github.Milestone) (problems []string) {
+
+	if !params.CreateNextMilestone || milestone == nil {
+		return
+	}
+
+	if _, err := bumpVersion(milestone.Title, params.NextMilestoneBump); err != nil {
+		problems = append(problems, fmt.Sprintf("Next milestone cannot be created: %v", err))
+	}
+	if params.NextMilestoneDueInDays < 0 {
+		problems = append(problems, fmt.Sprintf("Next milestone due date offset %v days cannot be negative", params.NextMilestoneDueInDays))
+	}
+
+	return
+}
+
+func checkArchiveFormat(options archiveOptions) (problems []string) {
+
+	if options.Format != archiveFormatZip && options.Format != archiveFormatTarGz {
+		problems = append(problems, fmt.Sprintf("Archive format %v is not supported, use %v or %v", options.Format, archiveFormatZip, archiveFormatTarGz))
+	}
+
+	return
+}
+
+func checkTimeouts(params Params) (problems []string) {
+
+	if params.TimeoutSeconds < 0 {
+		problems = append(problems, fmt.Sprintf("Timeout of %v seconds cannot be negative", params.TimeoutSeconds))
+	}
+	if params.RequestTimeoutSeconds < 0 {
+		problems = append(problems, fmt.Sprintf("Request timeout of %v seconds cannot be negative", params.RequestTimeoutSeconds))
+	}
+
+	return
+}
+
+func checkReleaseCommentTemplate(params Params) (problems []string) {
+
+	if !params.ReleaseComment {
+		return
+	}
+
+	if _, err := renderReleaseComment(params.ReleaseCommentTemplate, releaseCommentTemplateData{}); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	return
+}
+
+func checkNotifications(params Params) (problems []string) {
+
+	for i, n := range params.Notifications {
+		if err := validateNotificationParams(n); err != nil {
+			problems = append(problems, fmt.Sprintf("Notification %v: %v", i+1, err))
+		}
+	}
+
+	return
+}
+
+func checkAssetTemplates(params Params, repoName string, options archiveOptions) (releaseAssets []releaseAsset, problems []string) {
+
+	releaseAssets = make([]releaseAsset, 0, len(params.Assets))
+	for _, a := range params.Assets {
+		asset, err := a.render(params.ReleaseVersion, repoName, options)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("Asset %v: %v", a.Path, err))
+			continue
+		}
+		releaseAssets = append(releaseAssets, asset)
+	}
+
+	// github requires unique asset names within a release
+	names := map[string]string{}
+	for _, a := range releaseAssets {
+		if path, ok := names[a.Name]; ok {
+			problems = append(problems, fmt.Sprintf("Assets %v and %v both have name %v", path, a.Path, a.Name))
+		}
+		names[a.Name] = a.Path
+	}
+
+	return
+}
+
+func checkAssetFiles(assets []Asset) (problems []string) {
+
+	for _, a := range assets {
+		if a.Path == "" {
+			problems = append(problems, "Asset without path is configured")
+			continue
+		}
+
+		size, err := getAssetSize(a.Path)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("Asset %v cannot be read: %v", a.Path, err))
+			continue
+		}
+		if size >= maxReleaseAssetSize {
+			problems = append(problems, fmt.Sprintf("Asset %v is %v bytes, which exceeds Github's limit of %v bytes", a.Path, size, maxReleaseAssetSize))
+		}
+	}
+
+	return
+}
+
+// getAssetSize returns the size of a file or the total size of all files in a directory
+// getAssetSize returns the size of the files in the asset, collected as they're archived so invalid symlinks are reported as well
+func getAssetSize(path string) (size int64, err error) {
+
+	entries, err := collectArchiveEntries(path)
+	if err != nil {
+		return
+	}
+
+	for _, e := range entries {
+		if e.info.Mode().IsRegular() {
+			size += e.info.Size()
+		}
+	}
+
+	return
+}
+
+func checkTokenScopes(ctx context.Context, githubAPIClient github.APIClient) (problems []string) {
+
+	scopes, hasScopes, err := githubAPIClient.GetTokenScopes(ctx)
+	if err != nil {
+		return append(problems, fmt.Sprintf("Retrieving token scopes failed: %v", err))
+	}
+	if !hasScopes {
+		return
+	}
+
+	for _, s := range scopes {
+		for _, r := range requiredTokenScopes {
+			if s == r {
+				return
+			}
+		}
+	}
+
+	return append(problems, fmt.Sprintf("Token has scopes %v, but needs one of %v", scopes, requiredTokenScopes))
+}
+
+func checkDebug(params Params) (problems []string) {
+
+	if params.HARFile != "" && !params.Debug {
+		problems = append(problems, fmt.Sprintf("Parameter harFile %v is only written in debug mode, set debug to true as well", params.HARFile))
+	}
+
+	return
+}
@@ -0,0 +1,223 @@
+package release
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckMilestone(t *testing.T) {
+
+	t.Run("ReturnsProblemIfMilestoneIsMissing", func(t *testing.T) {
+
+		params := Params{ReleaseVersion: "1.2.0"}
+
+		// act
+		problems := checkMilestone(params, nil, &github.MilestoneNotFoundError{Version: "1.2.0"})
+
+		assert.Equal(t, 1, len(problems))
+	})
+
+	t.Run("ReturnsNoProblemsIfMissingMilestoneIsIgnored", func(t *testing.T) {
+
+		params := Params{ReleaseVersion: "1.2.0", MissingMilestonePolicy: missingMilestonePolicyIgnore}
+
+		// act
+		problems := checkMilestone(params, nil, &github.MilestoneNotFoundError{Version: "1.2.0"})
+
+		assert.Equal(t, 0, len(problems))
+	})
+
+	t.Run("ReturnsProblemIfPolicyFailsEvenIfDeprecatedFlagIgnoresMissingMilestone", func(t *testing.T) {
+
+		params := Params{ReleaseVersion: "1.2.0", IgnoreMissingMilestone: true, MissingMilestonePolicy: missingMilestonePolicyFail}
+
+		// act
+		problems := checkMilestone(params, nil, &github.MilestoneNotFoundError{Version: "1.2.0"})
+
+		assert.Equal(t, 1, len(problems))
+	})
+
+	t.Run("ReturnsProblemForOtherLookupErrorsIfMissingMilestoneIsCreated", func(t *testing.T) {
+
+		params := Params{ReleaseVersion: "1.2.0", MissingMilestonePolicy: missingMilestonePolicyCreate}
+
+		// act
+		problems := checkMilestone(params, nil, errors.New("Multiple milestones for version 1.2.0 match lookup prefix"))
+
+		assert.Equal(t, 1, len(problems))
+	})
+
+	t.Run("ReturnsProblemForInvalidPatternIfMissingMilestoneIsIgnored", func(t *testing.T) {
+
+		params := Params{ReleaseVersion: "1.2.0", MissingMilestonePolicy: missingMilestonePolicyIgnore, MilestoneLookup: "regex", MilestonePattern: "1.2.("}
+
+		// act
+		problems := checkMilestone(params, nil, &github.MilestoneNotFoundError{Version: "1.2.0"})
+
+		assert.Equal(t, 1, len(problems))
+	})
+
+	t.Run("ReturnsNoProblemsIfMissingMilestoneIsCreated", func(t *testing.T) {
+
+		params := Params{ReleaseVersion: "1.2.0", MissingMilestonePolicy: missingMilestonePolicyCreate}
+
+		// act
+		problems := checkMilestone(params, nil, &github.MilestoneNotFoundError{Version: "1.2.0"})
+
+		assert.Equal(t, 0, len(problems))
+	})
+
+	t.Run("ReturnsNoProblemsIfMilestoneExists", func(t *testing.T) {
+
+		params := Params{ReleaseVersion: "1.2.0"}
+
+		// act
+		problems := checkMilestone(params, &github.Milestone{Title: "1.2.0"}, nil)
+
+		assert.Equal(t, 0, len(problems))
+	})
+}
+
+func TestCheckOpenIssuesPolicy(t *testing.T) {
+
+	closeMilestone := true
+
+	t.Run("ReturnsProblemIfMilestoneHasOpenIssuesForFailPolicy", func(t *testing.T) {
+
+		params := Params{OpenIssuesPolicy: openIssuesPolicyFail, CloseMilestone: &closeMilestone}
+
+		// act
+		problems := checkOpenIssuesPolicy(params, &github.Milestone{Title: "1.2.0", State: "open", OpenIssues: 2})
+
+		assert.Equal(t, 1, len(problems))
+	})
+
+	t.Run("ReturnsNoProblemsIfMilestoneHasOpenIssuesForWarnPolicy", func(t *testing.T) {
+
+		params := Params{OpenIssuesPolicy: openIssuesPolicyWarn, CloseMilestone: &closeMilestone}
+
+		// act
+		problems := checkOpenIssuesPolicy(params, &github.Milestone{Title: "1.2.0", State: "open", OpenIssues: 2})
+
+		assert.Equal(t, 0, len(problems))
+	})
+
+	t.Run("ReturnsProblemIfMilestoneTitleCannotBeBumpedForMovePolicy", func(t *testing.T) {
+
+		params := Params{OpenIssuesPolicy: openIssuesPolicyMove, NextMilestoneBump: versionBumpMinor, CloseMilestone: &closeMilestone}
+
+		// act
+		problems := checkOpenIssuesPolicy(params, &github.Milestone{Title: "next", State: "open", OpenIssues: 2})
+
+		assert.Equal(t, 1, len(problems))
+	})
+}
+
+func TestCheckDebug(t *testing.T) {
+
+	t.Run("ReturnsProblemIfHARFileIsSetWithoutDebug", func(t *testing.T) {
+
+		params := Params{HARFile: "github-release.har"}
+
+		// act
+		problems := checkDebug(params)
+
+		assert.Equal(t, 1, len(problems))
+	})
+
+	t.Run("ReturnsNoProblemsIfHARFileIsSetWithDebug", func(t *testing.T) {
+
+		params := Params{Debug: true, HARFile: "github-release.har"}
+
+		// act
+		problems := checkDebug(params)
+
+		assert.Equal(t, 0, len(problems))
+	})
+}
+
+func TestCheckAssetFiles(t *testing.T) {
+
+	t.Run("ReturnsProblemForEachMissingAsset", func(t *testing.T) {
+
+		assets := []Asset{
+			{Path: "./does-not-exist-1"},
+			{Path: "./does-not-exist-2"},
+		}
+
+		// act
+		problems := checkAssetFiles(assets)
+
+		assert.Equal(t, 2, len(problems))
+	})
+
+	t.Run("ReturnsNoProblemsForExistingAsset", func(t *testing.T) {
+
+		dir, err := ioutil.TempDir("", "preflight")
+		assert.Nil(t, err)
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "estafette-cloudflare-dns")
+		assert.Nil(t, ioutil.WriteFile(path, []byte("binary content"), 0644))
+
+		// act
+		problems := checkAssetFiles([]Asset{{Path: path}})
+
+		assert.Equal(t, 0, len(problems))
+	})
+}
+
+func TestCheckAssetTemplates(t *testing.T) {
+
+	options := archiveOptions{Format: archiveFormatZip}
+
+	t.Run("ReturnsProblemForDuplicateAssetNames", func(t *testing.T) {
+
+		params := Params{
+			ReleaseVersion: "1.2.0",
+			Assets: []Asset{
+				{Path: "./linux/estafette-cloudflare-dns"},
+				{Path: "./darwin/estafette-cloudflare-dns"},
+			},
+		}
+
+		// act
+		_, problems := checkAssetTemplates(params, "estafette-cloudflare-dns", options)
+
+		assert.Equal(t, 1, len(problems))
+	})
+
+	t.Run("ReturnsProblemForInvalidTemplate", func(t *testing.T) {
+
+		params := Params{
+			ReleaseVersion: "1.2.0",
+			Assets: []Asset{
+				{Path: "./linux/estafette-cloudflare-dns", Name: "{{.Repo"},
+			},
+		}
+
+		// act
+		_, problems := checkAssetTemplates(params, "estafette-cloudflare-dns", options)
+
+		assert.Equal(t, 1, len(problems))
+	})
+}
+
+func TestPreflightError(t *testing.T) {
+
+	t.Run("ListsAllProblems", func(t *testing.T) {
+
+		err := &PreflightError{problems: []string{"Asset a cannot be read", "Asset b cannot be read"}}
+
+		// act
+		message := err.Error()
+
+		assert.Equal(t, "Preflight found 2 problem(s):\n- Asset a cannot be read\n- Asset b cannot be read", message)
+	})
+}
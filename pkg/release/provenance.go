@@ -0,0 +1,187 @@
+package release
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+)
+
+const (
+	inTotoStatementType     = "https://in-toto.io/Statement/v0.1"
+	slsaProvenancePredicate = "https://slsa.dev/provenance/v0.2"
+	provenanceBuildType     = "https://github.com/estafette/estafette-extension-github-release@v1"
+	defaultBuilderID        = "https://estafette.io"
+)
+
+type inTotoStatement struct {
+	Type          string            `json:"_type"`
+	Subject       []inTotoSubject   `json:"subject"`
+	PredicateType string            `json:"predicateType"`
+	Predicate     slsaProvenanceV02 `json:"predicate"`
+}
+
+type inTotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+type slsaProvenanceV02 struct {
+	Builder    slsaBuilder    `json:"builder"`
+	BuildType  string         `json:"buildType"`
+	Invocation slsaInvocation `json:"invocation"`
+	Metadata   slsaMetadata   `json:"metadata"`
+	Materials  []slsaMaterial `json:"materials,omitempty"`
+}
+
+type slsaBuilder struct {
+	ID string `json:"id"`
+}
+
+type slsaInvocation struct {
+	ConfigSource slsaConfigSource  `json:"configSource"`
+	Parameters   interface{}       `json:"parameters,omitempty"`
+	Environment  map[string]string `json:"environment,omitempty"`
+}
+
+type slsaConfigSource struct {
+	URI        string            `json:"uri"`
+	Digest     map[string]string `json:"digest"`
+	EntryPoint string            `json:"entryPoint,omitempty"`
+}
+
+type slsaMetadata struct {
+	BuildInvocationID string           `json:"buildInvocationId,omitempty"`
+	BuildStartedOn    *time.Time       `json:"buildStartedOn,omitempty"`
+	BuildFinishedOn   *time.Time       `json:"buildFinishedOn,omitempty"`
+	Completeness      slsaCompleteness `json:"completeness"`
+	Reproducible      bool             `json:"reproducible"`
+}
+
+type slsaCompleteness struct {
+	Parameters  bool `json:"parameters"`
+	Environment bool `json:"environment"`
+	Materials   bool `json:"materials"`
+}
+
+type slsaMaterial struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+// provenanceSource describes the repository and revision the released assets were built from
+type provenanceSource struct {
+	GitSource   string
+	RepoOwner   string
+	RepoName    string
+	GitRevision string
+	GitBranch   string
+}
+
+func (s provenanceSource) uri() string {
+	uri := "git+https://" + s.GitSource + "/" + s.RepoOwner + "/" + s.RepoName
+	if s.GitBranch != "" {
+		uri += "@refs/heads/" + s.GitBranch
+	}
+	return uri
+}
+
+func generateProvenanceStatement(assets []*github.ReleaseAsset, source provenanceSource, params Params, environment map[string]string, startedOn, finishedOn time.Time) inTotoStatement {
+
+	subjects := make([]inTotoSubject, 0, len(assets))
+	for _, a := range assets {
+		subjects = append(subjects, inTotoSubject{
+			Name:   a.Name,
+			Digest: map[string]string{"sha256": a.SHA256},
+		})
+	}
+
+	builderID := defaultBuilderID
+	if baseURL, ok := environment["ESTAFETTE_CI_SERVER_BASE_URL"]; ok && baseURL != "" {
+		builderID = strings.TrimSuffix(baseURL, "/")
+	}
+
+	buildInvocationID := environment["ESTAFETTE_BUILD_ID"]
+	if buildURL, ok := environment["ESTAFETTE_CI_SERVER_BUILD_URL"]; ok && buildURL != "" {
+		buildInvocationID = buildURL
+	}
+
+	// notification sinks contain webhook urls and credentials, which shouldn't end up in a public attestation
+	params.Notifications = nil
+
+	return inTotoStatement{
+		Type:          inTotoStatementType,
+		Subject:       subjects,
+		PredicateType: slsaProvenancePredicate,
+		Predicate: slsaProvenanceV02{
+			Builder: slsaBuilder{
+				ID: builderID,
+			},
+			BuildType: provenanceBuildType,
+			Invocation: slsaInvocation{
+				ConfigSource: slsaConfigSource{
+					URI:        source.uri(),
+					Digest:     map[string]string{"sha1": source.GitRevision},
+					EntryPoint: ".estafette.yaml",
+				},
+				Parameters:  params,
+				Environment: environment,
+			},
+			Metadata: slsaMetadata{
+				BuildInvocationID: buildInvocationID,
+				BuildStartedOn:    &startedOn,
+				BuildFinishedOn:   &finishedOn,
+				Completeness: slsaCompleteness{
+					Parameters:  true,
+					Environment: false,
+					Materials:   true,
+				},
+			},
+			Materials: []slsaMaterial{
+				{
+					URI:    source.uri(),
+					Digest: map[string]string{"sha1": source.GitRevision},
+				},
+			},
+		},
+	}
+}
+
+// marshalProvenanceStatement serializes the statement as a single json line, as expected in .intoto.jsonl files
+func marshalProvenanceStatement(statement inTotoStatement) ([]byte, error) {
+	data, err := json.Marshal(statement)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+// getEstafetteEnvironment returns all ESTAFETTE_* environment variables, leaving out the ones that can contain secrets
+func getEstafetteEnvironment() map[string]string {
+
+	environment := map[string]string{}
+	for _, e := range os.Environ() {
+		pair := strings.SplitN(e, "=", 2)
+		if len(pair) != 2 || !strings.HasPrefix(pair[0], "ESTAFETTE_") {
+			continue
+		}
+		if isSecretEnvironmentVariable(pair[0]) {
+			continue
+		}
+		environment[pair[0]] = pair[1]
+	}
+
+	return environment
+}
+
+func isSecretEnvironmentVariable(name string) bool {
+	for _, s := range []string{"CREDENTIALS", "TOKEN", "SECRET", "PASSWORD", "KEY", "CUSTOM_PROPERTIES"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
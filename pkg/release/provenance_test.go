@@ -0,0 +1,120 @@
+package release
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateProvenanceStatement(t *testing.T) {
+
+	source := provenanceSource{
+		GitSource:   "github.com",
+		RepoOwner:   "estafette",
+		RepoName:    "estafette-cloudflare-dns",
+		GitRevision: "f394515b2a16c5b5d4e9a3a1f8c7c1d0c5e6b7a8",
+		GitBranch:   "main",
+	}
+	assets := []*github.ReleaseAsset{
+		{
+			Name:   "estafette-cloudflare-dns.zip",
+			SHA256: "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		},
+	}
+	startedOn := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	finishedOn := startedOn.Add(time.Minute)
+
+	t.Run("AddsSubjectWithDigestForEachAsset", func(t *testing.T) {
+
+		// act
+		statement := generateProvenanceStatement(assets, source, Params{}, map[string]string{}, startedOn, finishedOn)
+
+		assert.Equal(t, inTotoStatementType, statement.Type)
+		assert.Equal(t, slsaProvenancePredicate, statement.PredicateType)
+		assert.Equal(t, 1, len(statement.Subject))
+		assert.Equal(t, "estafette-cloudflare-dns.zip", statement.Subject[0].Name)
+		assert.Equal(t, "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03", statement.Subject[0].Digest["sha256"])
+	})
+
+	t.Run("SetsConfigSourceToRepositoryAndRevision", func(t *testing.T) {
+
+		// act
+		statement := generateProvenanceStatement(assets, source, Params{}, map[string]string{}, startedOn, finishedOn)
+
+		assert.Equal(t, "git+https://github.com/estafette/estafette-cloudflare-dns@refs/heads/main", statement.Predicate.Invocation.ConfigSource.URI)
+		assert.Equal(t, "f394515b2a16c5b5d4e9a3a1f8c7c1d0c5e6b7a8", statement.Predicate.Invocation.ConfigSource.Digest["sha1"])
+	})
+
+	t.Run("NamesEstafetteServerAsBuilder", func(t *testing.T) {
+
+		environment := map[string]string{
+			"ESTAFETTE_CI_SERVER_BASE_URL":  "https://ci.estafette.io/",
+			"ESTAFETTE_CI_SERVER_BUILD_URL": "https://ci.estafette.io/pipelines/github.com/estafette/estafette-cloudflare-dns/builds/123",
+		}
+
+		// act
+		statement := generateProvenanceStatement(assets, source, Params{}, environment, startedOn, finishedOn)
+
+		assert.Equal(t, "https://ci.estafette.io", statement.Predicate.Builder.ID)
+		assert.Equal(t, "https://ci.estafette.io/pipelines/github.com/estafette/estafette-cloudflare-dns/builds/123", statement.Predicate.Metadata.BuildInvocationID)
+	})
+
+	t.Run("DefaultsBuilderIfServerBaseURLIsNotSet", func(t *testing.T) {
+
+		// act
+		statement := generateProvenanceStatement(assets, source, Params{}, map[string]string{}, startedOn, finishedOn)
+
+		assert.Equal(t, defaultBuilderID, statement.Predicate.Builder.ID)
+	})
+
+	t.Run("IncludesBuildParameters", func(t *testing.T) {
+
+		params := Params{ReleaseVersion: "1.2.0", Provenance: true}
+
+		// act
+		statement := generateProvenanceStatement(assets, source, params, map[string]string{}, startedOn, finishedOn)
+
+		assert.Equal(t, params, statement.Predicate.Invocation.Parameters)
+	})
+}
+
+func TestMarshalProvenanceStatement(t *testing.T) {
+
+	t.Run("ReturnsSingleJSONLine", func(t *testing.T) {
+
+		statement := inTotoStatement{Type: inTotoStatementType}
+
+		// act
+		data, err := marshalProvenanceStatement(statement)
+
+		assert.Nil(t, err)
+		assert.Equal(t, byte('\n'), data[len(data)-1])
+
+		var unmarshalled map[string]interface{}
+		err = json.Unmarshal(data, &unmarshalled)
+		assert.Nil(t, err)
+		assert.Equal(t, inTotoStatementType, unmarshalled["_type"])
+	})
+}
+
+func TestIsSecretEnvironmentVariable(t *testing.T) {
+
+	t.Run("ReturnsTrueForCustomPropertiesAsTheyCanContainSecrets", func(t *testing.T) {
+
+		// act
+		isSecret := isSecretEnvironmentVariable("ESTAFETTE_EXTENSION_CUSTOM_PROPERTIES_YAML")
+
+		assert.True(t, isSecret)
+	})
+
+	t.Run("ReturnsFalseForBuildVersion", func(t *testing.T) {
+
+		// act
+		isSecret := isSecretEnvironmentVariable("ESTAFETTE_BUILD_VERSION")
+
+		assert.False(t, isSecret)
+	})
+}
@@ -0,0 +1,66 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/estafette/estafette-extension-github-release/pkg/releasenotes"
+)
+
+const (
+	// ProviderGithub is github.com or a Github Enterprise host, released with Run
+	ProviderGithub = "github"
+	// ProviderGitlab is gitlab.com or a self-hosted Gitlab, released with RunProvider
+	ProviderGitlab = "gitlab"
+	// ProviderGitea is a self-hosted Gitea, released with RunProvider
+	ProviderGitea = "gitea"
+)
+
+// Provider creates releases from milestones in Gitlab or Gitea with RunProvider; Github has the full flow in Run
+type Provider interface {
+	Name() string
+	ChangeRequestsName() string
+	GetMilestoneByVersion(ctx context.Context, repoOwner, repoName, version string, lookup github.MilestoneLookup) (milestone *releasenotes.Milestone, err error)
+	GetIssuesAndChangeRequestsForMilestone(ctx context.Context, repoOwner, repoName string, milestone releasenotes.Milestone) (issues []*releasenotes.Issue, changeRequests []*releasenotes.ChangeRequest, err error)
+	CreateRelease(ctx context.Context, repoOwner, repoName string, release releasenotes.Release) (createdRelease *releasenotes.Release, err error)
+	UploadReleaseAsset(ctx context.Context, repoOwner, repoName string, release releasenotes.Release, name, label, contentType string, content []byte) (uploadedAsset *releasenotes.ReleaseAsset, err error)
+	CloseMilestone(ctx context.Context, repoOwner, repoName string, milestone releasenotes.Milestone) (err error)
+}
+
+// GetProviderSettings determines the provider and its api base url from the parameters or else from the estafette git source, like github.com or gitlab.example.com
+func GetProviderSettings(gitSource, provider, apiBaseURL string) (string, string, error) {
+
+	host := strings.ToLower(strings.TrimSpace(gitSource))
+
+	if provider == "" {
+		// only the host's first label is trusted to name the provider, so a host like github.gitlab-mirror.example.com stays Github; others set the provider explicitly
+		switch {
+		case host == "gitlab.com" || strings.HasPrefix(host, ProviderGitlab+"."):
+			provider = ProviderGitlab
+		case host == "gitea.com" || strings.HasPrefix(host, ProviderGitea+"."):
+			provider = ProviderGitea
+		default:
+			provider = ProviderGithub
+		}
+	}
+
+	if apiBaseURL != "" {
+		return provider, strings.TrimSuffix(apiBaseURL, "/"), nil
+	}
+
+	switch provider {
+	case ProviderGithub:
+		if host == "" || host == "github.com" {
+			return provider, "https://api.github.com", nil
+		}
+		return provider, fmt.Sprintf("https://%v/api/v3", host), nil
+	case ProviderGitlab:
+		return provider, fmt.Sprintf("https://%v/api/v4", host), nil
+	case ProviderGitea:
+		return provider, fmt.Sprintf("https://%v/api/v1", host), nil
+	}
+
+	return provider, "", fmt.Errorf("Provider %v is not supported, use %v, %v or %v", provider, ProviderGithub, ProviderGitlab, ProviderGitea)
+}
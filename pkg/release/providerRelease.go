@@ -0,0 +1,180 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/estafette/estafette-extension-github-release/pkg/releasenotes"
+	"github.com/rs/zerolog/log"
+)
+
+// RunProvider creates a release with notes from the milestone for providers other than Github, which only support the core of the release flow
+func RunProvider(ctx context.Context, provider Provider, run Options) (err error) {
+
+	params := run.Params
+	repoOwner, repoName, gitRevision := run.RepoOwner, run.RepoName, run.GitRevision
+
+	// without a commit api the timestamp for reproducible archives can only come from SOURCE_DATE_EPOCH
+	options := archiveOptions{
+		Format:       params.ArchiveFormat,
+		Reproducible: params.Reproducible,
+	}
+	if params.Reproducible && len(params.Assets) > 0 {
+		modTime, ok, err := getSourceDateEpoch()
+		if err != nil {
+			return failRun(run, err, "Determining timestamp for reproducible archives failed")
+		}
+		if !ok {
+			return failRun(run, fmt.Errorf("Reproducible archives for %v need SOURCE_DATE_EPOCH to be set", provider.Name()), "Determining timestamp for reproducible archives failed")
+		}
+		options.ModTime = modTime
+	}
+
+	// get milestone by version
+	milestone, milestoneErr := provider.GetMilestoneByVersion(ctx, repoOwner, repoName, params.ReleaseVersion, params.milestoneLookup())
+
+	// validate everything before making any changes
+	releaseAssets, err := runProviderPreflight(ctx, provider, repoName, params, milestone, milestoneErr, options)
+	if err != nil {
+		return failRun(run, err, "Preflight failed, nothing has been changed in %v", provider.Name())
+	}
+
+	var issues []*releasenotes.Issue
+	var changeRequests []*releasenotes.ChangeRequest
+	if milestone != nil {
+		issues, changeRequests, err = provider.GetIssuesAndChangeRequestsForMilestone(ctx, repoOwner, repoName, *milestone)
+		if err != nil {
+			return failRun(run, err, "Retrieving issues and %v for milestone %v failed", provider.ChangeRequestsName(), milestone.Title)
+		}
+	}
+
+	release := releasenotes.Release{
+		TagName:         fmt.Sprintf("v%v", params.ReleaseVersion),
+		TargetCommitish: gitRevision,
+		Name:            fmt.Sprintf("%v v%v", params.ReleaseTitle, params.ReleaseVersion),
+		Draft:           params.Draft,
+		PreRelease:      params.PreRelease,
+	}
+	if milestone != nil {
+		release.Body = releasenotes.Format(milestone, issues, changeRequests, provider.ChangeRequestsName())
+		release.Milestones = []string{milestone.Title}
+	}
+
+	// create release
+	createdRelease, err := provider.CreateRelease(ctx, repoOwner, repoName, release)
+	if err != nil {
+		return failRun(run, err, "Creating release with name %v failed", params.ReleaseVersion)
+	}
+
+	// upload assets
+	if createdRelease != nil {
+		release = *createdRelease
+		for _, a := range releaseAssets {
+			targetFilename, err := createArchive(a.Path, options)
+			if err != nil {
+				return failRun(run, err, "Archiving asset %v failed", a.Path)
+			}
+			content, err := ioutil.ReadFile(targetFilename)
+			if err != nil {
+				return failRun(run, err, "Reading archive %v failed", targetFilename)
+			}
+			_, err = provider.UploadReleaseAsset(ctx, repoOwner, repoName, release, a.Name, a.Label, options.contentType(), content)
+			if err != nil {
+				return failRun(run, err, "Uploading asset %v failed", a.Name)
+			}
+		}
+	}
+
+	// close milestone
+	if milestone != nil && milestone.State == "closed" {
+		log.Info().Msgf("Milestone %v is already closed", milestone.Title)
+	} else if milestone != nil && params.CloseMilestone != nil && *params.CloseMilestone {
+		err = provider.CloseMilestone(ctx, repoOwner, repoName, *milestone)
+		if err != nil {
+			return failRun(run, err, "Closing milestone %v failed", milestone.Title)
+		}
+	}
+
+	sendNotifications(ctx, run.Transport, params.Notifications, releaseNotification{
+		Succeeded:  true,
+		Repository: fmt.Sprintf("%v/%v", repoOwner, repoName),
+		Version:    params.ReleaseVersion,
+		Release:    &github.Release{TagName: release.TagName, Name: release.Name, HTMLURL: release.WebURL},
+		Notes:      release.Body,
+		BuildURL:   os.Getenv("ESTAFETTE_CI_SERVER_BUILD_URL"),
+	})
+
+	return nil
+}
+
+// runProviderPreflight validates the parameters for a provider other than Github, rejecting the features only implemented for Github
+func runProviderPreflight(ctx context.Context, provider Provider, repoName string, params Params, milestone *releasenotes.Milestone, milestoneErr error, options archiveOptions) (releaseAssets []releaseAsset, err error) {
+
+	log.Info().Msgf("Running preflight checks for %v...", provider.Name())
+
+	problems := make([]string, 0)
+
+	// the milestone checks only look at whether it was found
+	var ms *github.Milestone
+	if milestone != nil {
+		ms = &github.Milestone{Title: milestone.Title, State: milestone.State}
+	}
+	problems = append(problems, checkMilestone(params, ms, milestoneErr)...)
+	problems = append(problems, checkProviderSupport(provider.Name(), params)...)
+	problems = append(problems, checkArchiveFormat(options)...)
+	problems = append(problems, checkTimeouts(params)...)
+	problems = append(problems, checkNotifications(params)...)
+
+	releaseAssets, templateProblems := checkAssetTemplates(params, repoName, options)
+	problems = append(problems, templateProblems...)
+	problems = append(problems, checkAssetFiles(params.Assets)...)
+
+	if len(problems) > 0 {
+		return releaseAssets, &PreflightError{problems: problems}
+	}
+
+	log.Info().Msg("Preflight checks passed")
+
+	return releaseAssets, nil
+}
+
+// checkProviderSupport lists the parameters that are set but only supported for Github
+func checkProviderSupport(provider string, params Params) (problems []string) {
+
+	if provider == ProviderGithub {
+		return
+	}
+
+	unsupported := []struct {
+		name string
+		set  bool
+	}{
+		{"missingMilestonePolicy create", params.MissingMilestonePolicy == missingMilestonePolicyCreate},
+		{"syncMergedPullRequests", params.SyncMergedPullRequests},
+		{"openIssuesPolicy " + params.OpenIssuesPolicy, params.OpenIssuesPolicy != openIssuesPolicyWarn},
+		{"createNextMilestone", params.CreateNextMilestone},
+		{"releasedLabel", params.ReleasedLabel != ""},
+		{"releaseComment", params.ReleaseComment},
+		{"requireGreenBuild", params.RequireGreenBuild},
+		{"requireReachableFromBranch", params.RequireReachableFromBranch},
+		{"requireNoOpenIssues", params.RequireNoOpenIssues},
+		{"allowRetag", params.AllowRetag},
+		{"annotatedTag", params.AnnotatedTag},
+		{"provenance", params.Provenance},
+		{"rollbackOnFailure", params.RollbackOnFailure},
+		{"cacheDirectory", params.CacheDirectory != ""},
+		{"draft", params.Draft && provider == ProviderGitlab},
+		{"prerelease", params.PreRelease && provider == ProviderGitlab},
+	}
+
+	for _, u := range unsupported {
+		if u.set {
+			problems = append(problems, fmt.Sprintf("Parameter %v is not supported for %v", u.name, provider))
+		}
+	}
+
+	return
+}
@@ -0,0 +1,45 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/estafette/estafette-extension-github-release/pkg/gitlab"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunProvider(t *testing.T) {
+
+	t.Run("NotifiesFailureWhenPreflightFails", func(t *testing.T) {
+
+		var receivedBody []byte
+		webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			receivedBody, _ = ioutil.ReadAll(r.Body)
+		}))
+		defer webhook.Close()
+
+		// gitlab without milestones for the version
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[]`)
+		}))
+		defer server.Close()
+
+		params := Params{Notifications: []NotificationParams{
+			{Type: notificationTypeWebhook, URL: webhook.URL, OnFailure: true},
+		}}
+		params.SetDefaults("1.2.0", "app")
+		provider := gitlab.NewAPIClient(github.Options{BaseURL: server.URL + "/api/v4", AccessToken: "secret", RequestTimeout: time.Minute})
+
+		// act
+		err := RunProvider(context.Background(), provider, Options{RepoOwner: "estafette", RepoName: "app", GitRevision: fakeRevision, Params: params})
+
+		assert.NotNil(t, err)
+		assert.Contains(t, string(receivedBody), "Preflight failed, nothing has been changed in gitlab")
+	})
+}
@@ -0,0 +1,121 @@
+package release
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetProviderSettings(t *testing.T) {
+
+	t.Run("ReturnsGithubWithPublicApiForGithubCom", func(t *testing.T) {
+
+		// act
+		provider, apiBaseURL, err := GetProviderSettings("github.com", "", "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, ProviderGithub, provider)
+		assert.Equal(t, "https://api.github.com", apiBaseURL)
+	})
+
+	t.Run("ReturnsGithubEnterpriseApiForOtherHosts", func(t *testing.T) {
+
+		// act
+		provider, apiBaseURL, err := GetProviderSettings("git.example.com", "", "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, ProviderGithub, provider)
+		assert.Equal(t, "https://git.example.com/api/v3", apiBaseURL)
+	})
+
+	t.Run("ReturnsGitlabForGitlabCom", func(t *testing.T) {
+
+		// act
+		provider, apiBaseURL, err := GetProviderSettings("gitlab.com", "", "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, ProviderGitlab, provider)
+		assert.Equal(t, "https://gitlab.com/api/v4", apiBaseURL)
+	})
+
+	t.Run("ReturnsGiteaForHostsStartingWithGitea", func(t *testing.T) {
+
+		// act
+		provider, apiBaseURL, err := GetProviderSettings("gitea.example.com", "", "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, ProviderGitea, provider)
+		assert.Equal(t, "https://gitea.example.com/api/v1", apiBaseURL)
+	})
+
+	t.Run("ReturnsGithubForHostsContainingGitlabElsewhere", func(t *testing.T) {
+
+		// act
+		provider, apiBaseURL, err := GetProviderSettings("github.gitlab-mirror.example.com", "", "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, ProviderGithub, provider)
+		assert.Equal(t, "https://github.gitlab-mirror.example.com/api/v3", apiBaseURL)
+	})
+
+	t.Run("ReturnsExplicitProviderAndApiBaseURL", func(t *testing.T) {
+
+		// act
+		provider, apiBaseURL, err := GetProviderSettings("git.example.com", ProviderGitea, "https://git.example.com/gitea/api/v1/")
+
+		assert.Nil(t, err)
+		assert.Equal(t, ProviderGitea, provider)
+		assert.Equal(t, "https://git.example.com/gitea/api/v1", apiBaseURL)
+	})
+
+	t.Run("ReturnsErrorForUnsupportedProvider", func(t *testing.T) {
+
+		// act
+		_, _, err := GetProviderSettings("bitbucket.org", "bitbucket", "")
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestCheckProviderSupport(t *testing.T) {
+
+	t.Run("ReturnsNoProblemsForGithub", func(t *testing.T) {
+
+		params := Params{AnnotatedTag: true, OpenIssuesPolicy: openIssuesPolicyMove}
+
+		// act
+		problems := checkProviderSupport(ProviderGithub, params)
+
+		assert.Equal(t, 0, len(problems))
+	})
+
+	t.Run("ReturnsProblemForEachGithubOnlyParameter", func(t *testing.T) {
+
+		params := Params{AnnotatedTag: true, ReleaseComment: true, OpenIssuesPolicy: openIssuesPolicyWarn}
+
+		// act
+		problems := checkProviderSupport(ProviderGitea, params)
+
+		assert.Equal(t, []string{"Parameter releaseComment is not supported for gitea", "Parameter annotatedTag is not supported for gitea"}, problems)
+	})
+
+	t.Run("ReturnsNoProblemsForSharedHTTPSettings", func(t *testing.T) {
+
+		params := Params{Debug: true, HARFile: "gitlab-release.har", CABundlePath: "/certs/ca.pem", OpenIssuesPolicy: openIssuesPolicyWarn}
+
+		// act
+		problems := checkProviderSupport(ProviderGitlab, params)
+
+		assert.Equal(t, 0, len(problems))
+	})
+
+	t.Run("ReturnsProblemForDraftInGitlab", func(t *testing.T) {
+
+		params := Params{Draft: true, OpenIssuesPolicy: openIssuesPolicyWarn}
+
+		// act
+		problems := checkProviderSupport(ProviderGitlab, params)
+
+		assert.Equal(t, []string{"Parameter draft is not supported for gitlab"}, problems)
+	})
+}
@@ -0,0 +1,142 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/rs/zerolog/log"
+)
+
+// ReadinessError collects all failed release readiness gates
+type ReadinessError struct {
+	failures []string
+}
+
+func (e *ReadinessError) Error() string {
+	return fmt.Sprintf("%v release readiness gate(s) failed:\n- %v", len(e.failures), strings.Join(e.failures, "\n- "))
+}
+
+// runReadinessGates checks whether the revision is fit for release; with force set failed gates are logged as warning instead of returned
+func runReadinessGates(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName, gitRevision string, params Params, milestone *github.Milestone) (err error) {
+
+	if !params.RequireGreenBuild && !params.RequireReachableFromBranch && !params.RequireNoOpenIssues {
+		return nil
+	}
+
+	log.Info().Msg("Running release readiness gates...")
+
+	failures := make([]string, 0)
+	if params.RequireGreenBuild {
+		failures = append(failures, checkGreenBuild(ctx, githubAPIClient, repoOwner, repoName, gitRevision, params.IgnoreStatusContexts)...)
+	}
+	if params.RequireReachableFromBranch {
+		failures = append(failures, checkReachableFromBranch(ctx, githubAPIClient, repoOwner, repoName, gitRevision, params.ReleaseBranch)...)
+	}
+	if params.RequireNoOpenIssues {
+		failures = append(failures, checkNoOpenIssues(milestone)...)
+	}
+
+	if len(failures) == 0 {
+		log.Info().Msg("Release readiness gates passed")
+		return nil
+	}
+
+	err = &ReadinessError{failures: failures}
+	if params.Force {
+		log.Warn().Err(err).Msg("Release readiness gates failed, continuing because force is set")
+		return nil
+	}
+
+	return err
+}
+
+func checkGreenBuild(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName, gitRevision string, ignoreStatusContexts []string) (failures []string) {
+
+	combinedStatus, err := githubAPIClient.GetCombinedStatus(ctx, repoOwner, repoName, gitRevision)
+	if err != nil {
+		return append(failures, fmt.Sprintf("Retrieving commit status for %v failed: %v", gitRevision, err))
+	}
+	failures = append(failures, getFailedStatuses(combinedStatus.Statuses, ignoreStatusContexts)...)
+
+	checkRuns, err := githubAPIClient.GetCheckRuns(ctx, repoOwner, repoName, gitRevision)
+	if err != nil {
+		return append(failures, fmt.Sprintf("Retrieving check runs for %v failed: %v", gitRevision, err))
+	}
+	failures = append(failures, getFailedCheckRuns(checkRuns)...)
+
+	return failures
+}
+
+// getFailedStatuses returns all commit statuses that are not successful, except for the ignored contexts like the running build itself
+func getFailedStatuses(statuses []*github.Status, ignoreStatusContexts []string) (failures []string) {
+
+	for _, s := range statuses {
+		if s.State == "success" || isIgnoredStatusContext(s.Context, ignoreStatusContexts) {
+			continue
+		}
+		failures = append(failures, fmt.Sprintf("Commit status %v is %v", s.Context, s.State))
+	}
+
+	return
+}
+
+func isIgnoredStatusContext(context string, ignoreStatusContexts []string) bool {
+	for _, c := range ignoreStatusContexts {
+		if c == context {
+			return true
+		}
+	}
+	return false
+}
+
+// getFailedCheckRuns returns all check runs that haven't completed or didn't succeed
+func getFailedCheckRuns(checkRuns []*github.CheckRun) (failures []string) {
+
+	for _, c := range checkRuns {
+		if c.Status != "completed" {
+			failures = append(failures, fmt.Sprintf("Check run %v is %v", c.Name, c.Status))
+			continue
+		}
+		switch c.Conclusion {
+		case "success", "neutral", "skipped":
+		default:
+			failures = append(failures, fmt.Sprintf("Check run %v concluded %v", c.Name, c.Conclusion))
+		}
+	}
+
+	return
+}
+
+func checkReachableFromBranch(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName, gitRevision, releaseBranch string) (failures []string) {
+
+	branch := releaseBranch
+	if branch == "" {
+		repository, err := githubAPIClient.GetRepository(ctx, repoOwner, repoName)
+		if err != nil {
+			return append(failures, fmt.Sprintf("Retrieving default branch failed: %v", err))
+		}
+		branch = repository.DefaultBranch
+	}
+
+	// the revision is reachable from the branch if the branch contains it, meaning the revision is behind or identical to the branch
+	comparison, err := githubAPIClient.CompareCommits(ctx, repoOwner, repoName, branch, gitRevision)
+	if err != nil {
+		return append(failures, fmt.Sprintf("Comparing %v with branch %v failed: %v", gitRevision, branch, err))
+	}
+	if comparison.Status != "behind" && comparison.Status != "identical" {
+		failures = append(failures, fmt.Sprintf("Revision %v is not reachable from branch %v, it's %v by %v commit(s)", gitRevision, branch, comparison.Status, comparison.AheadBy))
+	}
+
+	return
+}
+
+func checkNoOpenIssues(milestone *github.Milestone) (failures []string) {
+
+	if milestone != nil && milestone.OpenIssues > 0 {
+		failures = append(failures, fmt.Sprintf("Milestone %v has %v open issues and pull requests", milestone.Title, milestone.OpenIssues))
+	}
+
+	return
+}
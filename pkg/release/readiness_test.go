@@ -0,0 +1,74 @@
+package release
+
+import (
+	"testing"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFailedStatuses(t *testing.T) {
+
+	t.Run("ReturnsStatusesThatAreNotSuccessful", func(t *testing.T) {
+
+		statuses := []*github.Status{
+			{Context: "ci/lint", State: "success"},
+			{Context: "ci/test", State: "failure"},
+			{Context: "ci/e2e", State: "pending"},
+		}
+
+		// act
+		failures := getFailedStatuses(statuses, []string{})
+
+		assert.Equal(t, []string{"Commit status ci/test is failure", "Commit status ci/e2e is pending"}, failures)
+	})
+
+	t.Run("IgnoresConfiguredContexts", func(t *testing.T) {
+
+		statuses := []*github.Status{
+			{Context: "estafette", State: "pending"},
+		}
+
+		// act
+		failures := getFailedStatuses(statuses, []string{"estafette"})
+
+		assert.Equal(t, 0, len(failures))
+	})
+}
+
+func TestGetFailedCheckRuns(t *testing.T) {
+
+	t.Run("ReturnsCheckRunsThatAreIncompleteOrUnsuccessful", func(t *testing.T) {
+
+		checkRuns := []*github.CheckRun{
+			{Name: "build", Status: "completed", Conclusion: "success"},
+			{Name: "docs", Status: "completed", Conclusion: "skipped"},
+			{Name: "test", Status: "completed", Conclusion: "failure"},
+			{Name: "scan", Status: "in_progress"},
+		}
+
+		// act
+		failures := getFailedCheckRuns(checkRuns)
+
+		assert.Equal(t, []string{"Check run test concluded failure", "Check run scan is in_progress"}, failures)
+	})
+}
+
+func TestCheckNoOpenIssues(t *testing.T) {
+
+	t.Run("ReturnsFailureIfMilestoneHasOpenIssues", func(t *testing.T) {
+
+		// act
+		failures := checkNoOpenIssues(&github.Milestone{Title: "1.2.0", OpenIssues: 3})
+
+		assert.Equal(t, []string{"Milestone 1.2.0 has 3 open issues and pull requests"}, failures)
+	})
+
+	t.Run("ReturnsNoFailuresIfMilestoneIsNil", func(t *testing.T) {
+
+		// act
+		failures := checkNoOpenIssues(nil)
+
+		assert.Equal(t, 0, len(failures))
+	})
+}
@@ -0,0 +1,345 @@
+// Package release creates releases from milestones in Github, Gitlab or Gitea; it holds the release flow of the estafette extension, so other Go programs can run the same flow
+package release
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/rs/zerolog/log"
+)
+
+// Options hold everything a release run needs besides the api client, so the same flow can run from the estafette extension, another Go program or against a fake Github api in tests; the Transport with the proxy and tls settings of the run sends the notifications and defaults to http.DefaultTransport
+type Options struct {
+	GitSource   string
+	RepoOwner   string
+	RepoName    string
+	GitRevision string
+	GitBranch   string
+	Params      Params
+	StartedOn   time.Time
+	Transport   http.RoundTripper
+}
+
+// Run orchestrates the release: preflight, tagging, creating the release, uploading assets and updating the milestone; changes are rolled back on failure if configured
+func Run(ctx context.Context, githubAPIClient github.APIClient, run Options) (err error) {
+
+	params := run.Params
+
+	// keep track of changes in Github to be able to report and roll them back
+	tracker := &mutationTracker{}
+	fail := func(err error, msg string, args ...interface{}) error {
+		tracker.report()
+		if params.RollbackOnFailure {
+			// roll back with a fresh context, the original one might be cancelled or past its deadline
+			if rollbackErr := tracker.rollback(context.Background()); rollbackErr != nil {
+				log.Error().Err(rollbackErr).Msg("Rolling back changes failed, please revert the remaining changes manually")
+			}
+		}
+		return failRun(run, err, msg, args...)
+	}
+
+	// get milestone by version
+	milestone, milestoneErr := githubAPIClient.GetMilestoneByVersion(ctx, run.RepoOwner, run.RepoName, params.ReleaseVersion, params.milestoneLookup())
+
+	archiveOptions := archiveOptions{
+		Format:       params.ArchiveFormat,
+		Reproducible: params.Reproducible,
+	}
+	if params.Reproducible && len(params.Assets) > 0 {
+		archiveOptions.ModTime, err = getReproducibleModTime(ctx, githubAPIClient, run)
+		if err != nil {
+			return fail(err, "Determining timestamp for reproducible archives failed")
+		}
+	}
+
+	// validate everything before making any changes
+	releaseAssets, err := runPreflight(ctx, githubAPIClient, run.RepoName, params, milestone, milestoneErr, archiveOptions)
+	if err != nil {
+		return fail(err, "Preflight failed, nothing has been changed in Github")
+	}
+
+	// check whether the revision is fit for release
+	err = runReadinessGates(ctx, githubAPIClient, run.RepoOwner, run.RepoName, run.GitRevision, params, milestone)
+	if err != nil {
+		return fail(err, "Release readiness gates failed, nothing has been changed in Github; set force to release anyway")
+	}
+
+	// check whether the tag already exists, to know whether creating the release creates it and whether it points to the right revision
+	tagName := fmt.Sprintf("v%v", params.ReleaseVersion)
+	existingTagRef, err := githubAPIClient.GetTagRef(ctx, run.RepoOwner, run.RepoName, tagName)
+	if err != nil {
+		return fail(err, "Retrieving tag %v failed", tagName)
+	}
+	existingTagSHA, err := checkExistingTag(ctx, githubAPIClient, run.RepoOwner, run.RepoName, tagName, run.GitRevision, existingTagRef, params.AllowRetag)
+	if err != nil {
+		return fail(err, "Tag safety check failed, nothing has been changed in Github")
+	}
+
+	// create missing milestone from the pull requests and issues since the previous release; preflight has rejected any other lookup error
+	if milestone == nil && github.IsMilestoneNotFoundError(milestoneErr) && params.MissingMilestonePolicy == missingMilestonePolicyCreate {
+		milestone, err = createMissingMilestone(ctx, githubAPIClient, run.RepoOwner, run.RepoName, params.ReleaseVersion, run.GitRevision, tracker)
+		if err != nil {
+			return fail(err, "Creating missing milestone %v failed", params.ReleaseVersion)
+		}
+	} else if milestone != nil && params.SyncMergedPullRequests {
+		err = syncMergedPullRequests(ctx, githubAPIClient, run.RepoOwner, run.RepoName, params.ReleaseVersion, run.GitRevision, *milestone, tracker)
+		if err != nil {
+			return fail(err, "Syncing merged pull requests to milestone #%v failed", milestone.Number)
+		}
+	}
+
+	var issues []*github.Issue
+	var pullRequests []*github.PullRequest
+
+	if milestone != nil {
+		// retrieve issues for milestone
+		issues, pullRequests, err = githubAPIClient.GetIssuesAndPullRequestsForMilestone(ctx, run.RepoOwner, run.RepoName, *milestone)
+		if err != nil {
+			return fail(err, "Retrieving issues and pull requests for milestone #%v failed", milestone.Number)
+		}
+	}
+
+	// create an annotated tag with the release notes as message, unless the tag already points to the revision
+	releaseName := fmt.Sprintf("%v v%v", params.ReleaseTitle, params.ReleaseVersion)
+	tagTargetSHA := run.GitRevision
+	if params.AnnotatedTag && (existingTagRef == nil || existingTagSHA != run.GitRevision) {
+		tagger, err := getTagger(ctx, githubAPIClient, run.RepoOwner, run.RepoName, run.GitRevision, params, time.Now().UTC())
+		if err != nil {
+			return fail(err, "Determining tagger for tag %v failed", tagName)
+		}
+		var notes string
+		if milestone != nil {
+			notes = formatReleaseNotes(milestone, issues, pullRequests)
+		}
+		tagTargetSHA, err = createAnnotatedTag(ctx, githubAPIClient, run.RepoOwner, run.RepoName, tagName, run.GitRevision, formatTagMessage(releaseName, notes), tagger)
+		if err != nil {
+			return fail(err, "Creating annotated tag %v failed", tagName)
+		}
+	}
+
+	// create the tag ref or move it if it exists on another commit and retagging is allowed
+	recordCreatedTag := func() {
+		tracker.record(fmt.Sprintf("Created tag %v", tagName), func(ctx context.Context) error {
+			return githubAPIClient.DeleteTagRef(ctx, run.RepoOwner, run.RepoName, tagName)
+		})
+	}
+	if existingTagRef != nil && existingTagSHA != run.GitRevision {
+		err = retag(ctx, githubAPIClient, run.RepoOwner, run.RepoName, tagName, run.GitRevision, tagTargetSHA, *existingTagRef, existingTagSHA, tracker)
+		if err != nil {
+			return fail(err, "Moving tag %v to revision %v failed", tagName, run.GitRevision)
+		}
+	} else if existingTagRef == nil && params.AnnotatedTag {
+		err = githubAPIClient.CreateTagRef(ctx, run.RepoOwner, run.RepoName, tagName, tagTargetSHA)
+		if err != nil {
+			return fail(err, "Creating tag %v failed", tagName)
+		}
+		recordCreatedTag()
+	}
+
+	// create release
+	createdRelease, err := createRelease(ctx, githubAPIClient, run.RepoOwner, run.RepoName, run.GitRevision, params.ReleaseVersion, milestone, issues, pullRequests, params)
+	if err != nil {
+		return fail(err, "Creating release with name %v failed", params.ReleaseVersion)
+	}
+	if createdRelease != nil && existingTagRef == nil && !params.AnnotatedTag && !createdRelease.Draft {
+		// github created the lightweight tag along with the release, except for a draft which gets its tag once published
+		recordCreatedTag()
+	}
+	if createdRelease != nil {
+		release := *createdRelease
+		tracker.record(fmt.Sprintf("Created release %v", release.Name), func(ctx context.Context) error {
+			return githubAPIClient.DeleteRelease(ctx, run.RepoOwner, run.RepoName, release)
+		})
+	}
+
+	// upload assets
+	if createdRelease != nil {
+		uploadedAssets, err := uploadReleaseAssets(ctx, githubAPIClient, *createdRelease, releaseAssets, archiveOptions)
+		if err != nil {
+			return fail(err, "Uploading assets %v failed", params.ReleaseVersion)
+		}
+
+		// upload provenance attestation for the uploaded assets
+		if params.Provenance {
+			source := provenanceSource{
+				GitSource:   run.GitSource,
+				RepoOwner:   run.RepoOwner,
+				RepoName:    run.RepoName,
+				GitRevision: run.GitRevision,
+				GitBranch:   run.GitBranch,
+			}
+			statement := generateProvenanceStatement(uploadedAssets, source, params, getEstafetteEnvironment(), run.StartedOn, time.Now().UTC())
+			provenance, err := marshalProvenanceStatement(statement)
+			if err != nil {
+				return fail(err, "Marshalling provenance statement failed")
+			}
+			_, err = githubAPIClient.UploadReleaseAsset(ctx, *createdRelease, fmt.Sprintf("%v.intoto.jsonl", createdRelease.TagName), "", "application/x-ndjson", provenance)
+			if err != nil {
+				return fail(err, "Uploading provenance for release %v failed", params.ReleaseVersion)
+			}
+		}
+	}
+
+	// label and comment on released issues and pull requests
+	if params.ReleasedLabel != "" || params.ReleaseComment {
+		release := createdRelease
+		if release == nil {
+			release, err = githubAPIClient.GetReleaseByTag(ctx, run.RepoOwner, run.RepoName, tagName)
+			if err != nil {
+				return fail(err, "Retrieving release for tag %v failed", tagName)
+			}
+		}
+		err = annotateReleasedIssues(ctx, githubAPIClient, run.RepoOwner, run.RepoName, params, *release, issues, pullRequests, tracker)
+		if err != nil {
+			return fail(err, "Labelling and commenting on released issues and pull requests failed")
+		}
+	}
+
+	// close milestone
+	if milestone != nil && milestone.State == "closed" {
+		log.Info().Msgf("Milestone %v is already closed", milestone.Title)
+	} else if milestone != nil && params.CloseMilestone != nil && *params.CloseMilestone {
+		err = handleOpenIssues(ctx, githubAPIClient, run.RepoOwner, run.RepoName, params, *milestone, tracker)
+		if err != nil {
+			return fail(err, "Handling open issues of milestone #%v failed", milestone.Number)
+		}
+
+		err = githubAPIClient.CloseMilestone(ctx, run.RepoOwner, run.RepoName, *milestone)
+		if err != nil {
+			return fail(err, "Closing milestone #%v failed", milestone.Number)
+		}
+		closedMilestone := *milestone
+		tracker.record(fmt.Sprintf("Closed milestone %v", closedMilestone.Title), func(ctx context.Context) error {
+			return githubAPIClient.ReopenMilestone(ctx, run.RepoOwner, run.RepoName, closedMilestone)
+		})
+	}
+
+	// create next milestone
+	if milestone != nil && params.CreateNextMilestone {
+		_, err = createNextMilestone(ctx, githubAPIClient, run.RepoOwner, run.RepoName, params, *milestone, tracker)
+		if err != nil {
+			return fail(err, "Creating next milestone after #%v failed", milestone.Number)
+		}
+	}
+
+	tracker.report()
+
+	// notify about the successful release
+	if len(params.Notifications) > 0 {
+		release := createdRelease
+		if release == nil {
+			release, err = githubAPIClient.GetReleaseByTag(ctx, run.RepoOwner, run.RepoName, tagName)
+			if err != nil {
+				log.Warn().Err(err).Msgf("Retrieving release for tag %v failed, notifying without release", tagName)
+			}
+		}
+		var notes string
+		if milestone != nil {
+			notes = formatReleaseNotes(milestone, issues, pullRequests)
+		}
+		sendNotifications(ctx, run.Transport, params.Notifications, releaseNotification{
+			Succeeded:  true,
+			Repository: fmt.Sprintf("%v/%v", run.RepoOwner, run.RepoName),
+			Version:    params.ReleaseVersion,
+			Release:    release,
+			Notes:      notes,
+			BuildURL:   os.Getenv("ESTAFETTE_CI_SERVER_BUILD_URL"),
+		})
+	}
+
+	return nil
+}
+
+// failRun notifies the sinks configured for failures and returns the error with the message; it's the single failure path of Run and RunProvider
+func failRun(run Options, err error, msg string, args ...interface{}) error {
+
+	err = fmt.Errorf("%v: %w", fmt.Sprintf(msg, args...), err)
+
+	// notify with a fresh context, a failure notification is most needed when the run timed out
+	sendNotifications(context.Background(), run.Transport, run.Params.Notifications, releaseNotification{
+		Succeeded:  false,
+		Repository: fmt.Sprintf("%v/%v", run.RepoOwner, run.RepoName),
+		Version:    run.Params.ReleaseVersion,
+		Error:      err.Error(),
+		BuildURL:   os.Getenv("ESTAFETTE_CI_SERVER_BUILD_URL"),
+	})
+
+	return err
+}
+
+// createRelease publishes the release with the notes from the milestone for the tag of the version
+func createRelease(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName, gitRevision, version string, milestone *github.Milestone, issues []*github.Issue, pullRequests []*github.PullRequest, params Params) (createdRelease *github.Release, err error) {
+
+	log.Info().Msgf("Creating release %v...", version)
+
+	tagName := fmt.Sprintf("v%v", version)
+	releaseName := fmt.Sprintf("%v v%v", params.ReleaseTitle, version)
+
+	var body string
+	if milestone != nil {
+		body = formatReleaseNotes(milestone, issues, pullRequests)
+	}
+
+	return githubAPIClient.PublishRelease(ctx, repoOwner, repoName, github.Release{
+		TagName:         tagName,
+		TargetCommitish: gitRevision,
+		Name:            releaseName,
+		Body:            body,
+		Draft:           params.Draft,
+		PreRelease:      params.PreRelease,
+	})
+}
+
+// uploadReleaseAssets archives the assets and uploads them to the release one by one
+func uploadReleaseAssets(ctx context.Context, githubAPIClient github.APIClient, createdRelease github.Release, assets []releaseAsset, options archiveOptions) (uploadedAssets []*github.ReleaseAsset, err error) {
+
+	uploadedAssets = make([]*github.ReleaseAsset, 0)
+	for _, a := range assets {
+
+		// archive file
+		targetFilename, err := createArchive(a.Path, options)
+		if err != nil {
+			return uploadedAssets, err
+		}
+
+		// read archive from disk
+		fileContent, err := ioutil.ReadFile(targetFilename)
+		if err != nil {
+			return uploadedAssets, err
+		}
+
+		// upload to github
+		uploadedAsset, err := githubAPIClient.UploadReleaseAsset(ctx, createdRelease, a.Name, a.Label, options.contentType(), fileContent)
+		if err != nil {
+			return uploadedAssets, err
+		}
+
+		uploadedAssets = append(uploadedAssets, uploadedAsset)
+	}
+
+	return uploadedAssets, nil
+}
+
+// getReproducibleModTime uses SOURCE_DATE_EPOCH if set and falls back to the commit time of the released revision
+func getReproducibleModTime(ctx context.Context, githubAPIClient github.APIClient, run Options) (time.Time, error) {
+
+	sourceDateEpoch, ok, err := getSourceDateEpoch()
+	if err != nil {
+		return sourceDateEpoch, err
+	}
+	if ok {
+		return sourceDateEpoch, nil
+	}
+
+	commit, err := githubAPIClient.GetCommit(ctx, run.RepoOwner, run.RepoName, run.GitRevision)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return commit.Commit.Committer.Date.UTC(), nil
+}
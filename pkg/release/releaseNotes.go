@@ -0,0 +1,27 @@
+package release
+
+import (
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/estafette/estafette-extension-github-release/pkg/releasenotes"
+)
+
+// formatReleaseNotes converts the Github milestone with its issues and pull requests, so they're formatted like those of the other providers
+func formatReleaseNotes(milestone *github.Milestone, issues []*github.Issue, pullRequests []*github.PullRequest) string {
+
+	var ms *releasenotes.Milestone
+	if milestone != nil {
+		ms = releasenotes.FromGithubMilestone(milestone)
+	}
+
+	neutralIssues := make([]*releasenotes.Issue, 0, len(issues))
+	for _, i := range issues {
+		neutralIssues = append(neutralIssues, releasenotes.FromGithubIssue(i))
+	}
+
+	changeRequests := make([]*releasenotes.ChangeRequest, 0, len(pullRequests))
+	for _, pr := range pullRequests {
+		changeRequests = append(changeRequests, releasenotes.FromGithubPullRequest(pr))
+	}
+
+	return releasenotes.Format(ms, neutralIssues, changeRequests, "pull requests")
+}
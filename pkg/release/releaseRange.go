@@ -0,0 +1,357 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	missingMilestonePolicyFail   = "fail"
+	missingMilestonePolicyIgnore = "ignore"
+	missingMilestonePolicyCreate = "create"
+)
+
+// linkedIssueRegex matches the keywords github uses to close issues from pull requests and commits, see https://help.github.com/en/github/managing-your-work-on-github/linking-a-pull-request-to-an-issue
+var linkedIssueRegex = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+#(\d+)\b`)
+
+// getLinkedIssueNumbers returns the numbers of the issues referenced with a closing keyword
+func getLinkedIssueNumbers(text string) (issueNumbers []int) {
+
+	for _, match := range linkedIssueRegex.FindAllStringSubmatch(text, -1) {
+		if number, err := strconv.Atoi(match[1]); err == nil {
+			issueNumbers = append(issueNumbers, number)
+		}
+	}
+
+	return
+}
+
+// findPreviousTag returns the tag with the highest semantic version below the version, or nil if there's none
+func findPreviousTag(tags []*github.Tag, version string) *github.Tag {
+
+	var previousTag *github.Tag
+	for _, t := range tags {
+		if !isSemanticVersion(t.Name) || compareVersions(t.Name, version) >= 0 {
+			continue
+		}
+		if previousTag == nil || compareVersions(t.Name, previousTag.Name) > 0 {
+			previousTag = t
+		}
+	}
+
+	return previousTag
+}
+
+func isSemanticVersion(version string) bool {
+	_, _, err := parseVersion(version)
+	return err == nil
+}
+
+// parseVersion splits a version in its major, minor and patch numbers and pre-release label
+func parseVersion(version string) (numbers []int, preRelease string, err error) {
+
+	normalizedVersion := github.NormalizeVersion(version)
+	if i := strings.Index(normalizedVersion, "+"); i >= 0 {
+		normalizedVersion = normalizedVersion[:i]
+	}
+	if i := strings.Index(normalizedVersion, "-"); i >= 0 {
+		preRelease = normalizedVersion[i+1:]
+		normalizedVersion = normalizedVersion[:i]
+	}
+
+	parts := strings.Split(normalizedVersion, ".")
+	if len(parts) != 3 {
+		return nil, "", fmt.Errorf("Version %v is not a valid semantic version", version)
+	}
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, "", fmt.Errorf("Version %v is not a valid semantic version", version)
+		}
+		numbers = append(numbers, n)
+	}
+
+	return numbers, preRelease, nil
+}
+
+// compareVersions returns -1, 0 or 1 when version a is lower than, equal to or higher than version b; invalid versions are the lowest
+func compareVersions(a, b string) int {
+
+	aNumbers, aPreRelease, aErr := parseVersion(a)
+	bNumbers, bPreRelease, bErr := parseVersion(b)
+	switch {
+	case aErr != nil && bErr != nil:
+		return 0
+	case aErr != nil:
+		return -1
+	case bErr != nil:
+		return 1
+	}
+
+	for i := range aNumbers {
+		if aNumbers[i] != bNumbers[i] {
+			if aNumbers[i] < bNumbers[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// a version without pre-release label is higher than the same version with one
+	switch {
+	case aPreRelease == bPreRelease:
+		return 0
+	case aPreRelease == "":
+		return 1
+	case bPreRelease == "":
+		return -1
+	}
+
+	return comparePreReleases(aPreRelease, bPreRelease)
+}
+
+// comparePreReleases compares pre-release labels by their dot separated identifiers, numerically if both are numeric, so rc.10 is higher than rc.9, see https://semver.org/#spec-item-11
+func comparePreReleases(a, b string) int {
+
+	aIdentifiers := strings.Split(a, ".")
+	bIdentifiers := strings.Split(b, ".")
+	for i := 0; i < len(aIdentifiers) && i < len(bIdentifiers); i++ {
+		aIdentifier, bIdentifier := aIdentifiers[i], bIdentifiers[i]
+		if aIdentifier == bIdentifier {
+			continue
+		}
+
+		aIsNumeric, bIsNumeric := isNumericIdentifier(aIdentifier), isNumericIdentifier(bIdentifier)
+		switch {
+		case aIsNumeric && bIsNumeric:
+			// without leading zeros the longer number is the higher one, which also works beyond the range of int
+			aIdentifier, bIdentifier = strings.TrimLeft(aIdentifier, "0"), strings.TrimLeft(bIdentifier, "0")
+			if len(aIdentifier) != len(bIdentifier) {
+				if len(aIdentifier) < len(bIdentifier) {
+					return -1
+				}
+				return 1
+			}
+			if aIdentifier == bIdentifier {
+				continue
+			}
+		case aIsNumeric:
+			// numeric identifiers have lower precedence than alphanumeric ones
+			return -1
+		case bIsNumeric:
+			return 1
+		}
+		if aIdentifier < bIdentifier {
+			return -1
+		}
+		return 1
+	}
+
+	// a larger set of identifiers is higher when all preceding ones are equal
+	switch {
+	case len(aIdentifiers) < len(bIdentifiers):
+		return -1
+	case len(aIdentifiers) > len(bIdentifiers):
+		return 1
+	}
+	return 0
+}
+
+func isNumericIdentifier(identifier string) bool {
+	if identifier == "" {
+		return false
+	}
+	for _, r := range identifier {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// getMergedPullRequestsInRange returns the pull requests merged between the previous release tag and the revision, and the numbers of the issues they close
+func getMergedPullRequestsInRange(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName, version, gitRevision string) (pullRequests []*github.PullRequest, linkedIssueNumbers []int, err error) {
+
+	tags, err := githubAPIClient.GetTags(ctx, repoOwner, repoName)
+	if err != nil {
+		return
+	}
+
+	previousTag := findPreviousTag(tags, version)
+	if previousTag == nil {
+		log.Info().Msgf("No tag for a version before %v found, skipping collecting pull requests", version)
+		return
+	}
+
+	comparison, err := githubAPIClient.CompareCommits(ctx, repoOwner, repoName, previousTag.Name, gitRevision)
+	if err != nil {
+		return
+	}
+	if len(comparison.Commits) < comparison.TotalCommits {
+		log.Warn().Msgf("Github returned %v of the %v commits between %v and %v, pull requests of the other commits are missing", len(comparison.Commits), comparison.TotalCommits, previousTag.Name, gitRevision)
+	}
+
+	// find the merged pull requests for each commit in the range
+	pullRequestsByNumber := map[int]*github.PullRequest{}
+	issueNumbers := map[int]bool{}
+	for _, c := range comparison.Commits {
+		for _, n := range getLinkedIssueNumbers(c.Commit.Message) {
+			issueNumbers[n] = true
+		}
+
+		commitPullRequests, err := githubAPIClient.GetPullRequestsForCommit(ctx, repoOwner, repoName, c.SHA)
+		if err != nil {
+			return pullRequests, linkedIssueNumbers, err
+		}
+		for _, pr := range commitPullRequests {
+			if pr.MergedAt == nil {
+				continue
+			}
+			pullRequestsByNumber[pr.Number] = pr
+			for _, n := range getLinkedIssueNumbers(pr.Body) {
+				issueNumbers[n] = true
+			}
+		}
+	}
+
+	pullRequests = make([]*github.PullRequest, 0, len(pullRequestsByNumber))
+	for _, pr := range pullRequestsByNumber {
+		pullRequests = append(pullRequests, pr)
+	}
+	sort.Slice(pullRequests, func(i, j int) bool {
+		return pullRequests[i].Number < pullRequests[j].Number
+	})
+
+	linkedIssueNumbers = make([]int, 0, len(issueNumbers))
+	for n := range issueNumbers {
+		if _, isPullRequest := pullRequestsByNumber[n]; !isPullRequest {
+			linkedIssueNumbers = append(linkedIssueNumbers, n)
+		}
+	}
+	sort.Ints(linkedIssueNumbers)
+
+	log.Info().Msgf("Found %v merged pull requests between %v and %v", len(pullRequests), previousTag.Name, gitRevision)
+
+	return pullRequests, linkedIssueNumbers, nil
+}
+
+// getClosedIssues retrieves the issues by number, skipping the ones that are still open or are pull requests
+func getClosedIssues(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName string, issueNumbers []int) (issues []*github.Issue) {
+
+	issues = make([]*github.Issue, 0, len(issueNumbers))
+	for _, n := range issueNumbers {
+		issue, err := githubAPIClient.GetIssue(ctx, repoOwner, repoName, n)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Retrieving issue #%v referenced in the release range failed, skipping it", n)
+			continue
+		}
+		if issue.PullRequest != nil || issue.State != "closed" {
+			continue
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues
+}
+
+// milestoneItem is an issue or pull request with the milestone it currently belongs to
+type milestoneItem struct {
+	number    int
+	milestone *github.Milestone
+}
+
+// assignToMilestone assigns issues and pull requests without milestone to the milestone; the ones already in another milestone are left untouched with a warning
+func assignToMilestone(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName string, milestone github.Milestone, items []milestoneItem, tracker *mutationTracker) (assigned []int, err error) {
+
+	for _, i := range items {
+		if i.milestone != nil && i.milestone.Number == milestone.Number {
+			continue
+		}
+		if i.milestone != nil {
+			log.Warn().Msgf("#%v belongs to milestone %v instead of %v, leaving it there", i.number, i.milestone.Title, milestone.Title)
+			continue
+		}
+
+		err = githubAPIClient.SetIssueMilestone(ctx, repoOwner, repoName, i.number, &milestone.Number)
+		if err != nil {
+			return
+		}
+
+		issueNumber := i.number
+		tracker.record(fmt.Sprintf("Assigned #%v to milestone %v", issueNumber, milestone.Title), func(ctx context.Context) error {
+			return githubAPIClient.SetIssueMilestone(ctx, repoOwner, repoName, issueNumber, nil)
+		})
+		assigned = append(assigned, i.number)
+	}
+
+	return assigned, nil
+}
+
+// createMissingMilestone creates the milestone for the version and assigns the pull requests and issues of the release range to it
+func createMissingMilestone(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName, version, gitRevision string, tracker *mutationTracker) (milestone *github.Milestone, err error) {
+
+	milestone, err = findOrCreateMilestone(ctx, githubAPIClient, repoOwner, repoName, github.MilestoneUpdateRequest{Title: version, State: "open"}, tracker)
+	if err != nil {
+		return
+	}
+
+	pullRequests, linkedIssueNumbers, err := getMergedPullRequestsInRange(ctx, githubAPIClient, repoOwner, repoName, version, gitRevision)
+	if err != nil {
+		return
+	}
+	issues := getClosedIssues(ctx, githubAPIClient, repoOwner, repoName, linkedIssueNumbers)
+
+	items := make([]milestoneItem, 0, len(pullRequests)+len(issues))
+	for _, pr := range pullRequests {
+		items = append(items, milestoneItem{number: pr.Number, milestone: pr.Milestone})
+	}
+	for _, i := range issues {
+		items = append(items, milestoneItem{number: i.Number, milestone: i.Milestone})
+	}
+
+	assigned, err := assignToMilestone(ctx, githubAPIClient, repoOwner, repoName, *milestone, items, tracker)
+	if err != nil {
+		return
+	}
+
+	log.Info().Msgf("Assigned %v issues and pull requests to milestone %v", len(assigned), milestone.Title)
+
+	return milestone, nil
+}
+
+// syncMergedPullRequests assigns pull requests merged since the previous release tag without milestone to the release milestone
+func syncMergedPullRequests(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName, version, gitRevision string, milestone github.Milestone, tracker *mutationTracker) (err error) {
+
+	log.Info().Msgf("Syncing merged pull requests to milestone %v...", milestone.Title)
+
+	pullRequests, _, err := getMergedPullRequestsInRange(ctx, githubAPIClient, repoOwner, repoName, version, gitRevision)
+	if err != nil {
+		return
+	}
+
+	items := make([]milestoneItem, 0, len(pullRequests))
+	for _, pr := range pullRequests {
+		items = append(items, milestoneItem{number: pr.Number, milestone: pr.Milestone})
+	}
+
+	assigned, err := assignToMilestone(ctx, githubAPIClient, repoOwner, repoName, milestone, items, tracker)
+	if err != nil {
+		return
+	}
+
+	if len(assigned) == 0 {
+		log.Info().Msg("All merged pull requests already belong to a milestone")
+		return nil
+	}
+
+	log.Info().Msgf("Assigned %v merged pull requests without milestone to milestone %v: %v", len(assigned), milestone.Title, formatIssueNumbers(assigned))
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package release
+
+import (
+	"testing"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLinkedIssueNumbers(t *testing.T) {
+
+	t.Run("ReturnsNumbersReferencedWithClosingKeywords", func(t *testing.T) {
+
+		// act
+		issueNumbers := getLinkedIssueNumbers("Add official helm chart\n\nFixes #12, closes #13 and Resolved: #14")
+
+		assert.Equal(t, []int{12, 13, 14}, issueNumbers)
+	})
+
+	t.Run("IgnoresReferencesWithoutClosingKeyword", func(t *testing.T) {
+
+		// act
+		issueNumbers := getLinkedIssueNumbers("Related to #12")
+
+		assert.Equal(t, 0, len(issueNumbers))
+	})
+}
+
+func TestFindPreviousTag(t *testing.T) {
+
+	tags := []*github.Tag{
+		{Name: "v1.1.0"},
+		{Name: "v1.2.0-beta"},
+		{Name: "v1.1.5"},
+		{Name: "v1.3.0"},
+		{Name: "latest"},
+	}
+
+	t.Run("ReturnsHighestVersionBelowVersion", func(t *testing.T) {
+
+		// act
+		tag := findPreviousTag(tags, "1.2.0")
+
+		assert.Equal(t, "v1.2.0-beta", tag.Name)
+	})
+
+	t.Run("ReturnsNilIfNoLowerVersionExists", func(t *testing.T) {
+
+		// act
+		tag := findPreviousTag(tags, "1.0.0")
+
+		assert.Nil(t, tag)
+	})
+}
+
+func TestCompareVersions(t *testing.T) {
+
+	t.Run("ReturnsMinusOneIfMinorIsLower", func(t *testing.T) {
+
+		// act
+		result := compareVersions("1.1.9", "v1.2.0")
+
+		assert.Equal(t, -1, result)
+	})
+
+	t.Run("ReturnsZeroForSameVersionWithDifferentNotation", func(t *testing.T) {
+
+		// act
+		result := compareVersions("v1.2", "1.2.0")
+
+		assert.Equal(t, 0, result)
+	})
+
+	t.Run("ReturnsOneForReleaseComparedToItsPreRelease", func(t *testing.T) {
+
+		// act
+		result := compareVersions("1.2.0", "1.2.0-beta")
+
+		assert.Equal(t, 1, result)
+	})
+
+	t.Run("ComparesNumericPreReleaseIdentifiersNumerically", func(t *testing.T) {
+
+		// act
+		result := compareVersions("1.2.0-rc.10", "1.2.0-rc.9")
+
+		assert.Equal(t, 1, result)
+	})
+
+	t.Run("ReturnsMinusOneForNumericComparedToAlphanumericIdentifier", func(t *testing.T) {
+
+		// act
+		result := compareVersions("1.2.0-1", "1.2.0-alpha")
+
+		assert.Equal(t, -1, result)
+	})
+
+	t.Run("ReturnsOneForPreReleaseWithMoreIdentifiers", func(t *testing.T) {
+
+		// act
+		result := compareVersions("1.2.0-alpha.1", "1.2.0-alpha")
+
+		assert.Equal(t, 1, result)
+	})
+
+	t.Run("OrdersPreReleasesAsInTheSpec", func(t *testing.T) {
+
+		versions := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0"}
+
+		for i := 1; i < len(versions); i++ {
+			// act
+			result := compareVersions(versions[i-1], versions[i])
+
+			assert.Equal(t, -1, result, "%v < %v", versions[i-1], versions[i])
+		}
+	})
+}
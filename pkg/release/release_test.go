@@ -0,0 +1,232 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/fakegithub"
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	fakeRevision = "0123456789abcdef0123456789abcdef01234567"
+)
+
+// newFakeGithubRelease starts a fake Github api with a milestone for version 1.2.0 and returns a client for it and a release run for the revision
+func newFakeGithubRelease(params Params) (*fakegithub.Server, github.APIClient, Options) {
+
+	server := fakegithub.NewServer("estafette", "app")
+	server.AddCommit(fakegithub.Commit{SHA: fakeRevision, Message: "Fix all the things", Date: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)})
+	server.AddMilestone(fakegithub.Milestone{Title: "1.2.0"})
+
+	client := github.NewAPIClient(github.Options{
+		BaseURL:        server.URL,
+		AccessToken:    "secret",
+		RequestTimeout: time.Minute,
+	})
+
+	params.SetDefaults("1.2.0", "app")
+
+	return server, client, Options{
+		GitSource:   "github.com",
+		RepoOwner:   "estafette",
+		RepoName:    "app",
+		GitRevision: fakeRevision,
+		GitBranch:   "master",
+		Params:      params,
+		StartedOn:   time.Now().UTC(),
+	}
+}
+
+func TestRunGithubRelease(t *testing.T) {
+
+	t.Run("CreatesReleaseWithNotesAndClosesMilestone", func(t *testing.T) {
+
+		server, client, run := newFakeGithubRelease(Params{})
+		defer server.Close()
+		server.AddIssue(fakegithub.Issue{Title: "Crash on startup", Milestone: 1, Assignee: "jorrit"})
+		server.AddIssue(fakegithub.Issue{Title: "Add retries", Milestone: 1, IsPullRequest: true})
+		server.AddIssue(fakegithub.Issue{Title: "Still open", Milestone: 1, State: "open"})
+
+		// act
+		err := Run(context.Background(), client, run)
+
+		assert.Nil(t, err)
+		releases := server.Releases()
+		if assert.Equal(t, 1, len(releases)) {
+			assert.Equal(t, "v1.2.0", releases[0].TagName)
+			assert.Equal(t, "App v1.2.0", releases[0].Name)
+			assert.Equal(t, fakeRevision, releases[0].TargetCommitish)
+			assert.True(t, strings.Contains(releases[0].Body, "Crash on startup"))
+			assert.True(t, strings.Contains(releases[0].Body, "Add retries"))
+			assert.False(t, strings.Contains(releases[0].Body, "Still open"))
+		}
+		assert.Equal(t, fakeRevision, server.Tags()["v1.2.0"])
+		assert.Equal(t, "closed", server.Milestones()[0].State)
+	})
+
+	t.Run("RetrievesAllPagesOfIssues", func(t *testing.T) {
+
+		server, client, run := newFakeGithubRelease(Params{})
+		defer server.Close()
+		server.PageSize = 2
+		for i := 0; i < 5; i++ {
+			server.AddIssue(fakegithub.Issue{Title: "Issue " + string('A'+rune(i)), Milestone: 1})
+		}
+
+		// act
+		err := Run(context.Background(), client, run)
+
+		assert.Nil(t, err)
+		releases := server.Releases()
+		if assert.Equal(t, 1, len(releases)) {
+			for i := 0; i < 5; i++ {
+				assert.True(t, strings.Contains(releases[0].Body, "Issue "+string('A'+rune(i))))
+			}
+		}
+	})
+
+	t.Run("UploadsAssets", func(t *testing.T) {
+
+		directory, _ := ioutil.TempDir("", "assets")
+		defer os.RemoveAll(directory)
+		ioutil.WriteFile(filepath.Join(directory, "app-linux-amd64"), []byte("binary"), 0644)
+
+		server, client, run := newFakeGithubRelease(Params{Assets: []Asset{{Path: filepath.Join(directory, "app-linux-amd64")}}})
+		defer server.Close()
+
+		// act
+		err := Run(context.Background(), client, run)
+
+		assert.Nil(t, err)
+		releases := server.Releases()
+		if assert.Equal(t, 1, len(releases)) && assert.Equal(t, 1, len(releases[0].Assets)) {
+			assert.Equal(t, "app-linux-amd64.zip", releases[0].Assets[0].Name)
+		}
+	})
+
+	t.Run("SkipsExistingRelease", func(t *testing.T) {
+
+		server, client, run := newFakeGithubRelease(Params{})
+		defer server.Close()
+		server.AddTag("v1.2.0", fakeRevision)
+		server.AddRelease(fakegithub.Release{TagName: "v1.2.0", Name: "App v1.2.0", Body: "Existing notes"})
+
+		// act
+		err := Run(context.Background(), client, run)
+
+		assert.Nil(t, err)
+		releases := server.Releases()
+		if assert.Equal(t, 1, len(releases)) {
+			assert.Equal(t, "Existing notes", releases[0].Body)
+		}
+		assert.Equal(t, "closed", server.Milestones()[0].State)
+	})
+
+	t.Run("FailsPreflightWithoutChangesWhenMilestoneIsMissing", func(t *testing.T) {
+
+		server, client, run := newFakeGithubRelease(Params{ReleaseVersion: "2.0.0"})
+		defer server.Close()
+
+		// act
+		err := Run(context.Background(), client, run)
+
+		assert.NotNil(t, err)
+		var preflightErr *PreflightError
+		assert.True(t, errors.As(err, &preflightErr))
+		assert.Equal(t, 0, len(server.Releases()))
+		assert.Equal(t, 0, len(server.Tags()))
+	})
+
+	t.Run("RollsBackReleaseAndTagWhenClosingMilestoneFails", func(t *testing.T) {
+
+		server, client, run := newFakeGithubRelease(Params{RollbackOnFailure: true})
+		defer server.Close()
+		server.FailRequests("PATCH", "/repos/estafette/app/milestones/1", http.StatusUnprocessableEntity, 1)
+
+		// act
+		err := Run(context.Background(), client, run)
+
+		assert.NotNil(t, err)
+		var apiError *github.APIError
+		if assert.True(t, errors.As(err, &apiError)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, apiError.StatusCode)
+		}
+		assert.Equal(t, 0, len(server.Releases()))
+		assert.Equal(t, 0, len(server.Tags()))
+		assert.Equal(t, "open", server.Milestones()[0].State)
+	})
+
+	t.Run("RollsBackAnnotatedTagWhenCreatingReleaseFails", func(t *testing.T) {
+
+		server, client, run := newFakeGithubRelease(Params{RollbackOnFailure: true, AnnotatedTag: true})
+		defer server.Close()
+		server.FailRequests("POST", "/repos/estafette/app/releases", http.StatusUnprocessableEntity, 1)
+
+		// act
+		err := Run(context.Background(), client, run)
+
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, len(server.Releases()))
+		assert.Equal(t, 0, len(server.Tags()))
+	})
+
+	t.Run("DoesNotRollBackTagForDraftRelease", func(t *testing.T) {
+
+		server, client, run := newFakeGithubRelease(Params{RollbackOnFailure: true, Draft: true})
+		defer server.Close()
+		server.FailRequests("PATCH", "/repos/estafette/app/milestones/1", http.StatusUnprocessableEntity, 1)
+
+		// act
+		err := Run(context.Background(), client, run)
+
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, len(server.Releases()))
+		assert.NotContains(t, server.Requests(), "DELETE /repos/estafette/app/git/refs/tags/v1.2.0")
+	})
+
+	t.Run("NotifiesFailureWhenRetrievingTagFails", func(t *testing.T) {
+
+		var receivedBody []byte
+		webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			receivedBody, _ = ioutil.ReadAll(r.Body)
+		}))
+		defer webhook.Close()
+
+		server, client, run := newFakeGithubRelease(Params{Notifications: []NotificationParams{
+			{Type: notificationTypeWebhook, URL: webhook.URL, OnFailure: true},
+		}})
+		defer server.Close()
+		server.FailRequests("GET", "/repos/estafette/app/git/ref/tags/v1.2.0", http.StatusInternalServerError, 10)
+
+		// act
+		err := Run(context.Background(), client, run)
+
+		assert.NotNil(t, err)
+		assert.Contains(t, string(receivedBody), "Retrieving tag v1.2.0 failed")
+		assert.Equal(t, 0, len(server.Releases()))
+	})
+
+	t.Run("KeepsChangesWhenFailingWithoutRollback", func(t *testing.T) {
+
+		server, client, run := newFakeGithubRelease(Params{})
+		defer server.Close()
+		server.FailRequests("PATCH", "/repos/estafette/app/milestones/1", http.StatusUnprocessableEntity, 1)
+
+		// act
+		err := Run(context.Background(), client, run)
+
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, len(server.Releases()))
+		assert.Equal(t, "open", server.Milestones()[0].State)
+	})
+}
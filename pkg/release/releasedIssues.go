@@ -0,0 +1,154 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultReleaseCommentTemplate = "Released in [{{.TagName}}]({{.URL}})"
+)
+
+// releaseCommentTemplateData holds the values available in the release comment template
+type releaseCommentTemplateData struct {
+	Version string
+	TagName string
+	Name    string
+	URL     string
+	Repo    string
+}
+
+// releasedItem is an issue or pull request included in a release
+type releasedItem struct {
+	number int
+	labels []*github.Label
+}
+
+func (i releasedItem) hasLabel(label string) bool {
+	for _, l := range i.labels {
+		if l.Name == label {
+			return true
+		}
+	}
+	return false
+}
+
+// getReleaseCommentMarker returns the hidden marker added to release comments, used to detect comments posted by earlier runs
+func getReleaseCommentMarker(tagName string) string {
+	return fmt.Sprintf("<!-- estafette-extension-github-release:%v -->", tagName)
+}
+
+func renderReleaseComment(commentTemplate string, data releaseCommentTemplateData) (string, error) {
+
+	tmpl, err := template.New("releaseComment").Parse(commentTemplate)
+	if err != nil {
+		return "", fmt.Errorf("Parsing release comment template '%v' failed: %v", commentTemplate, err)
+	}
+
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, data)
+	if err != nil {
+		return "", fmt.Errorf("Rendering release comment template '%v' failed: %v", commentTemplate, err)
+	}
+
+	return buffer.String() + "\n\n" + getReleaseCommentMarker(data.TagName), nil
+}
+
+// annotateReleasedIssues labels and comments on all issues and pull requests included in the release
+func annotateReleasedIssues(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName string, params Params, release github.Release, issues []*github.Issue, pullRequests []*github.PullRequest, tracker *mutationTracker) (err error) {
+
+	items := make([]releasedItem, 0, len(issues)+len(pullRequests))
+	for _, i := range issues {
+		items = append(items, releasedItem{number: i.Number, labels: i.Labels})
+	}
+	for _, pr := range pullRequests {
+		items = append(items, releasedItem{number: pr.Number, labels: pr.Labels})
+	}
+
+	var comment string
+	if params.ReleaseComment {
+		comment, err = renderReleaseComment(params.ReleaseCommentTemplate, releaseCommentTemplateData{
+			Version: params.ReleaseVersion,
+			TagName: release.TagName,
+			Name:    release.Name,
+			URL:     release.HTMLURL,
+			Repo:    repoName,
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	log.Info().Msgf("Annotating %v released issues and pull requests...", len(items))
+
+	for _, i := range items {
+		if params.ReleasedLabel != "" {
+			err = labelReleasedItem(ctx, githubAPIClient, repoOwner, repoName, params.ReleasedLabel, i, tracker)
+			if err != nil {
+				return
+			}
+		}
+		if params.ReleaseComment {
+			err = commentOnReleasedItem(ctx, githubAPIClient, repoOwner, repoName, comment, getReleaseCommentMarker(release.TagName), i, tracker)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	log.Info().Msgf("Annotated %v released issues and pull requests", len(items))
+
+	return nil
+}
+
+func labelReleasedItem(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName, label string, item releasedItem, tracker *mutationTracker) (err error) {
+
+	if item.hasLabel(label) {
+		log.Info().Msgf("#%v already has label %v", item.number, label)
+		return nil
+	}
+
+	err = githubAPIClient.AddLabelsToIssue(ctx, repoOwner, repoName, item.number, []string{label})
+	if err != nil {
+		return
+	}
+
+	issueNumber := item.number
+	tracker.record(fmt.Sprintf("Added label %v to #%v", label, issueNumber), func(ctx context.Context) error {
+		return githubAPIClient.RemoveLabelFromIssue(ctx, repoOwner, repoName, issueNumber, label)
+	})
+
+	return nil
+}
+
+func commentOnReleasedItem(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName, comment, marker string, item releasedItem, tracker *mutationTracker) (err error) {
+
+	comments, err := githubAPIClient.GetIssueComments(ctx, repoOwner, repoName, item.number)
+	if err != nil {
+		return
+	}
+	for _, c := range comments {
+		if strings.Contains(c.Body, marker) {
+			log.Info().Msgf("#%v already has a release comment", item.number)
+			return nil
+		}
+	}
+
+	createdComment, err := githubAPIClient.CreateIssueComment(ctx, repoOwner, repoName, item.number, comment)
+	if err != nil {
+		return
+	}
+
+	postedComment := *createdComment
+	tracker.record(fmt.Sprintf("Commented on #%v", item.number), func(ctx context.Context) error {
+		return githubAPIClient.DeleteIssueComment(ctx, repoOwner, repoName, postedComment)
+	})
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package release
+
+import (
+	"testing"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderReleaseComment(t *testing.T) {
+
+	data := releaseCommentTemplateData{
+		Version: "1.2.0",
+		TagName: "v1.2.0",
+		Name:    "Estafette-cloudflare-dns v1.2.0",
+		URL:     "https://github.com/estafette/estafette-cloudflare-dns/releases/tag/v1.2.0",
+		Repo:    "estafette-cloudflare-dns",
+	}
+
+	t.Run("RendersDefaultTemplateWithMarker", func(t *testing.T) {
+
+		// act
+		comment, err := renderReleaseComment(defaultReleaseCommentTemplate, data)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "Released in [v1.2.0](https://github.com/estafette/estafette-cloudflare-dns/releases/tag/v1.2.0)\n\n<!-- estafette-extension-github-release:v1.2.0 -->", comment)
+	})
+
+	t.Run("ReturnsErrorForInvalidTemplate", func(t *testing.T) {
+
+		// act
+		_, err := renderReleaseComment("Released in {{.TagName", data)
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestReleasedItemHasLabel(t *testing.T) {
+
+	item := releasedItem{number: 12, labels: []*github.Label{{Name: "bug"}, {Name: "released"}}}
+
+	t.Run("ReturnsTrueIfItemHasLabel", func(t *testing.T) {
+
+		// act
+		hasLabel := item.hasLabel("released")
+
+		assert.True(t, hasLabel)
+	})
+
+	t.Run("ReturnsFalseIfItemDoesNotHaveLabel", func(t *testing.T) {
+
+		// act
+		hasLabel := item.hasLabel("enhancement")
+
+		assert.False(t, hasLabel)
+	})
+}
@@ -0,0 +1,64 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// mutation is a change made in Github, with the function to revert it
+type mutation struct {
+	description string
+	undo        func(ctx context.Context) error
+}
+
+// mutationTracker keeps track of all changes made in Github, so they can be reported and rolled back when a later step fails
+type mutationTracker struct {
+	mutations []mutation
+}
+
+func (t *mutationTracker) record(description string, undo func(ctx context.Context) error) {
+	log.Info().Msgf("Recorded change: %v", description)
+	t.mutations = append(t.mutations, mutation{description: description, undo: undo})
+}
+
+func (t *mutationTracker) report() {
+	if len(t.mutations) == 0 {
+		log.Info().Msg("No changes have been made in Github")
+		return
+	}
+
+	log.Info().Msgf("The following %v change(s) have been made in Github:", len(t.mutations))
+	for _, m := range t.mutations {
+		log.Info().Msgf("- %v", m.description)
+	}
+}
+
+// rollback reverts all recorded changes in reverse order; it continues when reverting a change fails and returns all failures at the end
+func (t *mutationTracker) rollback(ctx context.Context) error {
+
+	log.Info().Msgf("Rolling back %v change(s)...", len(t.mutations))
+
+	failures := make([]string, 0)
+	for i := len(t.mutations) - 1; i >= 0; i-- {
+		m := t.mutations[i]
+		log.Info().Msgf("Reverting: %v...", m.description)
+		if err := m.undo(ctx); err != nil {
+			log.Warn().Err(err).Msgf("Reverting failed: %v", m.description)
+			failures = append(failures, fmt.Sprintf("%v: %v", m.description, err))
+			continue
+		}
+		log.Info().Msgf("Reverted: %v", m.description)
+	}
+	t.mutations = nil
+
+	if len(failures) > 0 {
+		return fmt.Errorf("Rolling back failed for %v change(s):\n- %v", len(failures), strings.Join(failures, "\n- "))
+	}
+
+	log.Info().Msg("Rolled back all changes")
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutationTrackerRollback(t *testing.T) {
+
+	t.Run("RevertsChangesInReverseOrder", func(t *testing.T) {
+
+		tracker := mutationTracker{}
+		reverted := []string{}
+		tracker.record("Created release v1.2.0", func(ctx context.Context) error {
+			reverted = append(reverted, "release")
+			return nil
+		})
+		tracker.record("Closed milestone #1", func(ctx context.Context) error {
+			reverted = append(reverted, "milestone")
+			return nil
+		})
+
+		// act
+		err := tracker.rollback(context.Background())
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"milestone", "release"}, reverted)
+	})
+
+	t.Run("ContinuesAfterFailureAndReturnsError", func(t *testing.T) {
+
+		tracker := mutationTracker{}
+		reverted := []string{}
+		tracker.record("Created release v1.2.0", func(ctx context.Context) error {
+			reverted = append(reverted, "release")
+			return nil
+		})
+		tracker.record("Closed milestone #1", func(ctx context.Context) error {
+			return errors.New("Status code 500")
+		})
+
+		// act
+		err := tracker.rollback(context.Background())
+
+		assert.NotNil(t, err)
+		assert.Equal(t, []string{"release"}, reverted)
+	})
+}
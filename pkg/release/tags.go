@@ -0,0 +1,134 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+	"github.com/rs/zerolog/log"
+)
+
+// resolveTagCommit returns the sha of the commit a tag ref points to, dereferencing annotated tags
+func resolveTagCommit(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName string, ref github.Ref) (sha string, err error) {
+
+	object := ref.Object
+	for object.Type == "tag" {
+		tag, err := githubAPIClient.GetTagObject(ctx, repoOwner, repoName, object.SHA)
+		if err != nil {
+			return "", err
+		}
+		object = tag.Object
+	}
+
+	if object.Type != "commit" {
+		return "", fmt.Errorf("Tag %v points to a %v instead of a commit", ref.Ref, object.Type)
+	}
+
+	return object.SHA, nil
+}
+
+// checkExistingTag verifies an existing tag points to the revision to release and returns the commit it points to; a tag on another commit is only accepted with allowRetag
+func checkExistingTag(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName, tagName, gitRevision string, ref *github.Ref, allowRetag bool) (existingSHA string, err error) {
+
+	if ref == nil {
+		return "", nil
+	}
+
+	existingSHA, err = resolveTagCommit(ctx, githubAPIClient, repoOwner, repoName, *ref)
+	if err != nil {
+		return
+	}
+
+	if existingSHA == gitRevision {
+		log.Info().Msgf("Tag %v already points to revision %v", tagName, gitRevision)
+		return existingSHA, nil
+	}
+
+	if !allowRetag {
+		return existingSHA, fmt.Errorf("Tag %v already exists on commit %v instead of revision %v; set allowRetag to move it", tagName, existingSHA, gitRevision)
+	}
+
+	log.Warn().Msgf("Tag %v exists on commit %v instead of revision %v, it will be moved because allowRetag is set", tagName, existingSHA, gitRevision)
+
+	return existingSHA, nil
+}
+
+// retag moves an existing tag to the revision, or to an annotated tag object for the revision, and logs an audit entry; the original ref is restored on rollback
+func retag(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName, tagName, gitRevision, targetSHA string, ref github.Ref, existingSHA string, tracker *mutationTracker) (err error) {
+
+	err = githubAPIClient.UpdateTagRef(ctx, repoOwner, repoName, tagName, targetSHA)
+	if err != nil {
+		return
+	}
+
+	log.Warn().
+		Str("audit", "retag").
+		Str("repository", fmt.Sprintf("%v/%v", repoOwner, repoName)).
+		Str("tag", tagName).
+		Str("previousSha", existingSHA).
+		Str("newSha", gitRevision).
+		Str("buildVersion", os.Getenv("ESTAFETTE_BUILD_VERSION")).
+		Str("buildUrl", os.Getenv("ESTAFETTE_CI_SERVER_BUILD_URL")).
+		Msgf("Moved tag %v from commit %v to %v", tagName, existingSHA, gitRevision)
+
+	// restore the ref to the original object, which is the tag object for annotated tags
+	originalObjectSHA := ref.Object.SHA
+	tracker.record(fmt.Sprintf("Moved tag %v from %v to %v", tagName, existingSHA, gitRevision), func(ctx context.Context) error {
+		return githubAPIClient.UpdateTagRef(ctx, repoOwner, repoName, tagName, originalObjectSHA)
+	})
+
+	return nil
+}
+
+// getTagger returns the configured tagger identity, falling back to the committer of the revision
+func getTagger(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName, gitRevision string, params Params, now time.Time) (tagger github.Tagger, err error) {
+
+	tagger = github.Tagger{
+		Name:  params.TaggerName,
+		Email: params.TaggerEmail,
+		Date:  now.Format(time.RFC3339),
+	}
+
+	if tagger.Name == "" || tagger.Email == "" {
+		commit, err := githubAPIClient.GetCommit(ctx, repoOwner, repoName, gitRevision)
+		if err != nil {
+			return tagger, err
+		}
+		if tagger.Name == "" {
+			tagger.Name = commit.Commit.Committer.Name
+		}
+		if tagger.Email == "" {
+			tagger.Email = commit.Commit.Committer.Email
+		}
+	}
+
+	return tagger, nil
+}
+
+// formatTagMessage builds the annotated tag message from the release name and notes
+func formatTagMessage(releaseName, notes string) string {
+	if notes == "" {
+		return releaseName
+	}
+
+	return releaseName + "\n\n" + notes
+}
+
+// createAnnotatedTag creates an annotated tag object with the release notes as message and returns its sha
+func createAnnotatedTag(ctx context.Context, githubAPIClient github.APIClient, repoOwner, repoName, tagName, gitRevision, message string, tagger github.Tagger) (sha string, err error) {
+
+	tag, err := githubAPIClient.CreateTagObject(ctx, repoOwner, repoName, github.TagCreateRequest{
+		Tag:     tagName,
+		Message: message,
+		Object:  gitRevision,
+		Type:    "commit",
+		Tagger:  tagger,
+	})
+	if err != nil {
+		return
+	}
+
+	return tag.SHA, nil
+}
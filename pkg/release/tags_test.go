@@ -0,0 +1,26 @@
+package release
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatTagMessage(t *testing.T) {
+
+	t.Run("ReturnsReleaseNameIfNotesAreEmpty", func(t *testing.T) {
+
+		// act
+		message := formatTagMessage("Estafette-cloudflare-dns v1.2.0", "")
+
+		assert.Equal(t, "Estafette-cloudflare-dns v1.2.0", message)
+	})
+
+	t.Run("ReturnsReleaseNameAndNotesSeparatedByEmptyLine", func(t *testing.T) {
+
+		// act
+		message := formatTagMessage("Estafette-cloudflare-dns v1.2.0", "**Resolved issues (1)**\n* Add official helm chart. [#12](https://github.com/estafette/estafette-cloudflare-dns/issues/12)\n")
+
+		assert.Equal(t, "Estafette-cloudflare-dns v1.2.0\n\n**Resolved issues (1)**\n* Add official helm chart. [#12](https://github.com/estafette/estafette-cloudflare-dns/issues/12)\n", message)
+	})
+}
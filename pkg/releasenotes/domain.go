@@ -0,0 +1,67 @@
+package releasenotes
+
+// Milestone is the provider independent representation of a milestone
+type Milestone struct {
+	// ID identifies the milestone in api calls of the provider
+	ID          int
+	Title       string
+	State       string
+	Description string
+	WebURL      string
+}
+
+// Issue is the provider independent representation of a resolved issue
+type Issue struct {
+	Number int
+	// Reference is how the issue is referred to in the provider's markdown, like #12
+	Reference string
+	Title     string
+	State     string
+	WebURL    string
+	Assignee  *User
+	Labels    []string
+}
+
+// ChangeRequest is the provider independent representation of a pull request in Github and Gitea or a merge request in Gitlab
+type ChangeRequest struct {
+	Number int
+	// Reference is how the change request is referred to in the provider's markdown, like #12 or !12
+	Reference string
+	Title     string
+	State     string
+	WebURL    string
+	Merged    bool
+	Assignee  *User
+	Labels    []string
+}
+
+// User is the provider independent representation of a user
+type User struct {
+	Login  string
+	WebURL string
+}
+
+// Release is the provider independent representation of a release
+type Release struct {
+	// ID identifies the release in api calls of the provider, Gitlab uses the tag name instead
+	ID              int
+	TagName         string
+	TargetCommitish string
+	Name            string
+	Body            string
+	Draft           bool
+	PreRelease      bool
+	// Milestones are the titles of milestones to associate the release with, if the provider supports it
+	Milestones []string
+	WebURL     string
+	UploadURL  string
+}
+
+// ReleaseAsset is the provider independent representation of an uploaded release asset
+type ReleaseAsset struct {
+	Name        string
+	Label       string
+	ContentType string
+	Size        int
+	DownloadURL string
+}
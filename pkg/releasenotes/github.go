@@ -0,0 +1,64 @@
+package releasenotes
+
+import (
+	"fmt"
+
+	"github.com/estafette/estafette-extension-github-release/pkg/github"
+)
+
+// FromGithubMilestone converts a Github milestone to its provider independent representation
+func FromGithubMilestone(milestone *github.Milestone) *Milestone {
+	return &Milestone{
+		ID:          milestone.Number,
+		Title:       milestone.Title,
+		State:       milestone.State,
+		Description: milestone.Description,
+		WebURL:      milestone.HTMLURL,
+	}
+}
+
+// FromGithubIssue converts a Github issue to its provider independent representation
+func FromGithubIssue(issue *github.Issue) *Issue {
+	return &Issue{
+		Number:    issue.Number,
+		Reference: fmt.Sprintf("#%v", issue.Number),
+		Title:     issue.Title,
+		State:     issue.State,
+		WebURL:    issue.HTMLURL,
+		Assignee:  FromGithubUser(issue.Assignee),
+		Labels:    getLabelNames(issue.Labels),
+	}
+}
+
+// FromGithubPullRequest converts a Github pull request to a change request
+func FromGithubPullRequest(pullRequest *github.PullRequest) *ChangeRequest {
+	return &ChangeRequest{
+		Number:    pullRequest.Number,
+		Reference: fmt.Sprintf("#%v", pullRequest.Number),
+		Title:     pullRequest.Title,
+		State:     pullRequest.State,
+		WebURL:    pullRequest.HTMLURL,
+		Merged:    pullRequest.MergedAt != nil,
+		Assignee:  FromGithubUser(pullRequest.Assignee),
+		Labels:    getLabelNames(pullRequest.Labels),
+	}
+}
+
+// FromGithubUser converts a Github user to its provider independent representation; nil stays nil
+func FromGithubUser(user *github.User) *User {
+	if user == nil {
+		return nil
+	}
+	return &User{
+		Login:  user.Login,
+		WebURL: user.HTMLURL,
+	}
+}
+
+func getLabelNames(labels []*github.Label) []string {
+	names := make([]string, 0, len(labels))
+	for _, l := range labels {
+		names = append(names, l.Name)
+	}
+	return names
+}
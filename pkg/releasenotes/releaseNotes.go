@@ -0,0 +1,58 @@
+// Package releasenotes formats the notes of a release from the issues and change requests in its milestone, independent of the git hosting provider
+package releasenotes
+
+import (
+	"fmt"
+)
+
+// Format lists the resolved issues and merged change requests with a link to the milestone in markdown
+func Format(milestone *Milestone, issues []*Issue, changeRequests []*ChangeRequest, changeRequestsName string) string {
+
+	response := ""
+
+	// list resolved issues
+	if len(issues) > 0 {
+		response += fmt.Sprintf("**Resolved issues (%v)**\n", len(issues))
+	}
+	for _, i := range issues {
+		response += fmt.Sprintf("* %v. [%v](%v)", i.Title, i.Reference, i.WebURL)
+		if i.Assignee != nil {
+			response += formatAssignee(*i.Assignee)
+		}
+		response += "\n"
+	}
+
+	if len(issues) > 0 && len(changeRequests) > 0 {
+		response += "\n"
+	}
+
+	// list merged change requests
+	if len(changeRequests) > 0 {
+		response += fmt.Sprintf("**Merged %v (%v)**\n", changeRequestsName, len(changeRequests))
+	}
+	for _, cr := range changeRequests {
+		response += fmt.Sprintf("* %v. [%v](%v)", cr.Title, cr.Reference, cr.WebURL)
+		if cr.Assignee != nil {
+			response += formatAssignee(*cr.Assignee)
+		}
+		response += "\n"
+	}
+
+	if milestone != nil && (len(issues) > 0 || len(changeRequests) > 0) {
+		response += "\n"
+	}
+
+	// link to milestone
+	if milestone != nil {
+		response += fmt.Sprintf("See [milestone %v](%v) for more details.", milestone.Title, fmt.Sprintf("%v?closed=1", milestone.WebURL))
+	}
+
+	return response
+}
+
+func formatAssignee(user User) string {
+	if user.WebURL == "" {
+		return fmt.Sprintf(", @%v", user.Login)
+	}
+	return fmt.Sprintf(", [@%v](%v)", user.Login, user.WebURL)
+}
@@ -0,0 +1,38 @@
+package releasenotes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatReleaseNotes(t *testing.T) {
+
+	t.Run("ListsMergeRequestsWithTheirReference", func(t *testing.T) {
+
+		milestone := &Milestone{Title: "1.2.0", WebURL: "https://gitlab.com/estafette/app/-/milestones/3"}
+		issues := []*Issue{
+			{Reference: "#12", Title: "Fix crash", WebURL: "https://gitlab.com/estafette/app/-/issues/12", Assignee: &User{Login: "jorrit", WebURL: "https://gitlab.com/jorrit"}},
+		}
+		changeRequests := []*ChangeRequest{
+			{Reference: "!7", Title: "Add retries", WebURL: "https://gitlab.com/estafette/app/-/merge_requests/7"},
+		}
+
+		// act
+		notes := Format(milestone, issues, changeRequests, "merge requests")
+
+		assert.Equal(t, "**Resolved issues (1)**\n* Fix crash. [#12](https://gitlab.com/estafette/app/-/issues/12), [@jorrit](https://gitlab.com/jorrit)\n\n**Merged merge requests (1)**\n* Add retries. [!7](https://gitlab.com/estafette/app/-/merge_requests/7)\n\nSee [milestone 1.2.0](https://gitlab.com/estafette/app/-/milestones/3?closed=1) for more details.", notes)
+	})
+
+	t.Run("MentionsAssigneeWithoutLinkIfProviderHasNoProfileURL", func(t *testing.T) {
+
+		issues := []*Issue{
+			{Reference: "#3", Title: "Fix crash", WebURL: "https://gitea.example.com/estafette/app/issues/3", Assignee: &User{Login: "jorrit"}},
+		}
+
+		// act
+		notes := Format(nil, issues, nil, "pull requests")
+
+		assert.Equal(t, "**Resolved issues (1)**\n* Fix crash. [#3](https://gitea.example.com/estafette/app/issues/3), @jorrit\n", notes)
+	})
+}